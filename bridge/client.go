@@ -0,0 +1,187 @@
+package bridge
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cgxeiji/max3010x/internal/frame"
+)
+
+// RemoteError is returned when the other side answers a request with
+// CmdError.
+type RemoteError struct {
+	Msg string
+}
+
+func (e *RemoteError) Error() string {
+	return "bridge: remote error: " + e.Msg
+}
+
+// DefaultTimeout is the default time to wait for a response.
+const DefaultTimeout = 1 * time.Second
+
+// Client is a max30102.Bus that forwards register accesses over a serial
+// port. A Client is safe for concurrent use.
+type Client struct {
+	mu      sync.Mutex
+	rw      io.ReadWriteCloser
+	r       *bufio.Reader
+	timeout time.Duration
+	// stale is set after a failed request, as the rest of its response
+	// could still be buffered.
+	stale bool
+}
+
+// NewClient returns a new client that communicates through rw, such as a
+// serial port opened with Open or one end of a pseudo-terminal pair. If rw
+// supports read deadlines, as a serial port opened with Open does, requests
+// fail when no response is received within DefaultTimeout (see SetTimeout).
+func NewClient(rw io.ReadWriteCloser) *Client {
+	return &Client{
+		rw:      rw,
+		r:       frame.NewReader(rw),
+		timeout: DefaultTimeout,
+	}
+}
+
+// SetTimeout sets the time to wait for a response. A timeout of 0 waits
+// forever.
+func (c *Client) SetTimeout(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.timeout = d
+}
+
+// request sends a request and returns the payload of a response of type
+// want.
+func (c *Client) request(cmd byte, payload []byte, want byte) ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stale {
+		c.r.Discard(c.r.Buffered())
+		c.stale = false
+	}
+	if err := c.deadline(); err != nil {
+		return nil, err
+	}
+
+	if err := frame.Write(c.rw, cmd, payload); err != nil {
+		return nil, err
+	}
+
+	got, resp, err := frame.Read(c.r)
+	if err != nil {
+		c.stale = true
+		return nil, err
+	}
+	switch got {
+	case want:
+		return resp, nil
+	case CmdError:
+		return nil, &RemoteError{Msg: string(resp)}
+	}
+
+	return nil, fmt.Errorf("bridge: unexpected response %#x to command %#x", got, cmd)
+}
+
+// deadline sets the read deadline of the serial port for a request.
+func (c *Client) deadline() error {
+	d, ok := c.rw.(interface{ SetReadDeadline(time.Time) error })
+	if !ok {
+		return nil
+	}
+
+	var t time.Time
+	if c.timeout > 0 {
+		t = time.Now().Add(c.timeout)
+	}
+	if err := d.SetReadDeadline(t); err != nil && !errors.Is(err, os.ErrNoDeadline) {
+		return fmt.Errorf("bridge: could not set read deadline: %w", err)
+	}
+
+	return nil
+}
+
+// Read reads a single byte from a register.
+func (c *Client) Read(reg byte) (byte, error) {
+	b, err := c.ReadBytes(reg, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	return b[0], nil
+}
+
+// ReadBytes reads n bytes starting from a register.
+func (c *Client) ReadBytes(reg byte, n int) ([]byte, error) {
+	b := make([]byte, n)
+	if err := c.ReadInto(reg, b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// ReadInto reads len(b) bytes starting from a register into b.
+func (c *Client) ReadInto(reg byte, b []byte) error {
+	if len(b) > MaxPayload {
+		return ErrFrameTooLong
+	}
+
+	req := []byte{reg, 0, 0}
+	binary.BigEndian.PutUint16(req[1:], uint16(len(b)))
+	resp, err := c.request(CmdRead, req, CmdData)
+	if err != nil {
+		return fmt.Errorf("bridge: could not read register %#x: %w", reg, err)
+	}
+	if len(resp) != len(b) {
+		return fmt.Errorf("bridge: could not read register %#x: got %d bytes, want %d", reg, len(resp), len(b))
+	}
+	copy(b, resp)
+
+	return nil
+}
+
+// Write writes a byte to a register.
+func (c *Client) Write(reg, data byte) error {
+	if _, err := c.request(CmdWrite, []byte{reg, data}, CmdAck); err != nil {
+		return fmt.Errorf("bridge: could not write %#x to register %#x: %w", data, reg, err)
+	}
+
+	return nil
+}
+
+// FIFO returns the FIFO pointers and all the available samples of
+// sampleSize bytes in a single request. Set full if the AlmostFull flag was
+// just seen, so that the FIFO is dumped whole if its pointers are equal.
+func (c *Client) FIFO(sampleSize int, full bool) (wr, ovf, rd byte, data []byte, err error) {
+	if sampleSize <= 0 || sampleSize > 0xFF {
+		return 0, 0, 0, nil, errors.New("bridge: invalid sample size")
+	}
+
+	req := []byte{byte(sampleSize), 0}
+	if full {
+		req[1] = 1
+	}
+	resp, err := c.request(CmdFIFO, req, CmdDump)
+	if err != nil {
+		return 0, 0, 0, nil, fmt.Errorf("bridge: could not dump FIFO: %w", err)
+	}
+	if len(resp) < 3 {
+		return 0, 0, 0, nil, fmt.Errorf("bridge: could not dump FIFO: short response")
+	}
+
+	return resp[0], resp[1], resp[2], resp[3:], nil
+}
+
+// Close closes the serial port.
+func (c *Client) Close() {
+	c.rw.Close()
+}
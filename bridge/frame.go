@@ -0,0 +1,51 @@
+// Package bridge implements a max30102.Bus over a serial port, for sensors
+// wired to a microcontroller that forwards register accesses over UART (e.g.
+// an Arduino connected through USB).
+//
+// Every message is a frame:
+//
+//	0xA5 | command (1) | length (2, big-endian) | payload (length) | CRC-8
+//
+// The CRC-8 (polynomial 0x07, initial value 0x00) covers the command, length
+// and payload bytes. The host sends one request and waits for its response:
+//
+//	CmdRead  [reg, n (2)]    -> CmdData  [n bytes read from reg]
+//	CmdWrite [reg, data]     -> CmdAck   []
+//	CmdFIFO  [sample size, full] -> CmdDump [wr, ovf, rd, available samples]
+//
+// When the FIFO pointers are equal, the FIFO is dumped whole if samples were
+// lost, or if full is 1 because the host just saw the AlmostFull flag. The
+// full byte is optional and defaults to 0.
+//
+// Any request can be answered with CmdError, whose payload is an error
+// message. Corrupted frames are dropped, and the receiver resyncs to the next
+// start of frame. Serve implements the microcontroller side and can be used as a
+// reference for firmware.
+package bridge
+
+import "github.com/cgxeiji/max3010x/internal/frame"
+
+// Frame constants
+const (
+	SOF = frame.SOF
+
+	CmdRead  = 0x01
+	CmdWrite = 0x02
+	CmdFIFO  = 0x03
+
+	CmdData  = 0x81
+	CmdAck   = 0x82
+	CmdDump  = 0x83
+	CmdError = 0xFF
+
+	// MaxPayload is the maximum length of the payload of a frame.
+	MaxPayload = frame.MaxPayload
+)
+
+var (
+	// ErrCRC is returned when a frame is received with a wrong checksum.
+	ErrCRC = frame.ErrCRC
+	// ErrFrameTooLong is returned when sending a payload longer than
+	// MaxPayload.
+	ErrFrameTooLong = frame.ErrTooLong
+)
@@ -0,0 +1,82 @@
+package bridge
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/cgxeiji/max3010x/internal/frame"
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+const fifoDepth = 32
+
+// Serve answers the requests received from rw by accessing bus, as the
+// firmware of the microcontroller would. Corrupted requests are dropped
+// without an answer, and the host times out. It returns nil when rw reaches
+// EOF.
+func Serve(rw io.ReadWriter, bus max30102.Bus) error {
+	r := frame.NewReader(rw)
+	for {
+		cmd, payload, err := frame.Read(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		} else if errors.Is(err, ErrCRC) {
+			// Answering would get the host out of sync if the corrupted
+			// frame holds more false starts of frame.
+			continue
+		} else if err != nil {
+			return err
+		}
+
+		resp, data, err := handle(bus, cmd, payload)
+		if err != nil {
+			resp, data = CmdError, []byte(err.Error())
+		}
+		if err := frame.Write(rw, resp, data); err != nil {
+			return err
+		}
+	}
+}
+
+func handle(bus max30102.Bus, cmd byte, payload []byte) (byte, []byte, error) {
+	switch cmd {
+	case CmdRead:
+		if len(payload) != 3 {
+			return 0, nil, errors.New("invalid read request")
+		}
+		n := binary.BigEndian.Uint16(payload[1:])
+		b, err := bus.ReadBytes(payload[0], int(n))
+		return CmdData, b, err
+
+	case CmdWrite:
+		if len(payload) != 2 {
+			return 0, nil, errors.New("invalid write request")
+		}
+		return CmdAck, nil, bus.Write(payload[0], payload[1])
+
+	case CmdFIFO:
+		if len(payload) < 1 || len(payload) > 2 || payload[0] == 0 {
+			return 0, nil, errors.New("invalid FIFO request")
+		}
+		full := len(payload) == 2 && payload[1] == 1
+		ptrs, err := bus.ReadBytes(max30102.FIFOWrPtr, 3)
+		if err != nil {
+			return 0, nil, err
+		}
+		wr, ovf, rd := ptrs[0], ptrs[1], ptrs[2]
+		n := (int(wr) + fifoDepth - int(rd)) % fifoDepth
+		if n == 0 && (ovf != 0 || full) {
+			n = fifoDepth
+		}
+		data := []byte{}
+		if n > 0 {
+			if data, err = bus.ReadBytes(max30102.FIFOData, n*int(payload[0])); err != nil {
+				return 0, nil, err
+			}
+		}
+		return CmdDump, append(ptrs, data...), nil
+	}
+
+	return 0, nil, errors.New("unknown command")
+}
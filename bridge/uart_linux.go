@@ -0,0 +1,74 @@
+//go:build linux
+// +build linux
+
+package bridge
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// cbaud is the mask of the baud rate bits in the control flags.
+const cbaud = 0010017
+
+var baudRates = map[int]uint32{
+	9600:   syscall.B9600,
+	19200:  syscall.B19200,
+	38400:  syscall.B38400,
+	57600:  syscall.B57600,
+	115200: syscall.B115200,
+	230400: syscall.B230400,
+	460800: syscall.B460800,
+	921600: syscall.B921600,
+}
+
+// Open opens the serial port at path (e.g. "/dev/ttyACM0") in raw mode with
+// 8N1 framing at the given baud rate, and returns a client that talks to the
+// bridge on the other side.
+func Open(path string, baud int) (*Client, error) {
+	speed, ok := baudRates[baud]
+	if !ok {
+		return nil, fmt.Errorf("bridge: unsupported baud rate %d", baud)
+	}
+
+	// In non-blocking mode, the port is handled by the runtime poller, which
+	// implements the read deadlines of the client.
+	f, err := os.OpenFile(path, os.O_RDWR|syscall.O_NOCTTY|syscall.O_NONBLOCK, 0)
+	if err != nil {
+		return nil, fmt.Errorf("bridge: could not open serial port: %w", err)
+	}
+
+	var t syscall.Termios
+	if err := ioctl(f, syscall.TCGETS, &t); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("bridge: could not get serial port attributes: %w", err)
+	}
+
+	t.Iflag &^= syscall.IGNBRK | syscall.BRKINT | syscall.PARMRK | syscall.ISTRIP |
+		syscall.INLCR | syscall.IGNCR | syscall.ICRNL | syscall.IXON
+	t.Oflag &^= syscall.OPOST
+	t.Lflag &^= syscall.ECHO | syscall.ECHONL | syscall.ICANON | syscall.ISIG | syscall.IEXTEN
+	t.Cflag &^= syscall.CSIZE | syscall.PARENB | syscall.CSTOPB | cbaud
+	t.Cflag |= syscall.CS8 | syscall.CREAD | syscall.CLOCAL | speed
+	t.Ispeed = speed
+	t.Ospeed = speed
+	t.Cc[syscall.VMIN] = 1
+	t.Cc[syscall.VTIME] = 0
+
+	if err := ioctl(f, syscall.TCSETS, &t); err != nil {
+		f.Close()
+		return nil, fmt.Errorf("bridge: could not set serial port attributes: %w", err)
+	}
+
+	return NewClient(f), nil
+}
+
+func ioctl(f *os.File, req uintptr, t *syscall.Termios) error {
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), req, uintptr(unsafe.Pointer(t)))
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
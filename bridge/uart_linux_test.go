@@ -0,0 +1,116 @@
+//go:build linux
+// +build linux
+
+package bridge
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/cgxeiji/max3010x/emulator"
+	"github.com/cgxeiji/max3010x/internal/frame"
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+// openPTY opens a pseudo-terminal pair and returns its master and the path
+// of its slave.
+func openPTY(t *testing.T) (*os.File, string) {
+	t.Helper()
+
+	m, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminal: %v", err)
+	}
+	var unlock int32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, m.Fd(), syscall.TIOCSPTLCK, uintptr(unsafe.Pointer(&unlock))); errno != 0 {
+		m.Close()
+		t.Skipf("could not unlock pseudo-terminal: %v", errno)
+	}
+	var n uint32
+	if _, _, errno := syscall.Syscall(syscall.SYS_IOCTL, m.Fd(), syscall.TIOCGPTN, uintptr(unsafe.Pointer(&n))); errno != 0 {
+		m.Close()
+		t.Skipf("could not get pseudo-terminal number: %v", errno)
+	}
+
+	return m, "/dev/pts/" + strconv.Itoa(int(n))
+}
+
+func TestPTYRoundTrip(t *testing.T) {
+	m, slave := openPTY(t)
+	defer m.Close()
+	go Serve(m, emulator.New(emulator.Pulse(72, 97)))
+
+	c, err := Open(slave, 115200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := max30102.NewWithBus(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	if _, err := d.Temperature(); err != nil {
+		t.Errorf("Temperature() = %v", err)
+	}
+	ir, red, err := d.IRRed()
+	if err != nil {
+		t.Fatalf("IRRed() = %v", err)
+	}
+	if ir <= 0 || red <= 0 {
+		t.Errorf("IRRed() = %v, %v, want positive values", ir, red)
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	_, _, _, data, err := c.FIFO(6, false)
+	if err != nil {
+		t.Fatalf("FIFO() = %v", err)
+	}
+	if len(data) == 0 || len(data)%6 != 0 {
+		t.Errorf("FIFO() returned %d bytes, want samples of 6 bytes", len(data))
+	}
+}
+
+func TestPTYTimeout(t *testing.T) {
+	m, slave := openPTY(t)
+	defer m.Close()
+
+	// Answer the first request with a false start of frame claiming a long
+	// payload, then serve normally.
+	go func() {
+		if _, _, err := frame.Read(frame.NewReader(m)); err != nil {
+			return
+		}
+		m.Write([]byte{SOF, CmdData, 0x10, 0x00})
+		Serve(m, emulator.New(emulator.Pulse(72, 97)))
+	}()
+
+	c, err := Open(slave, 115200)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	c.SetTimeout(100 * time.Millisecond)
+
+	start := time.Now()
+	if _, err := c.Read(max30102.RegPartID); !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("Read() = %v, want os.ErrDeadlineExceeded", err)
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("Read() timed out after %v, want about 100ms", d)
+	}
+
+	// The client drops what is left of the failed response.
+	part, err := c.Read(max30102.RegPartID)
+	if err != nil {
+		t.Fatalf("Read() after timeout = %v", err)
+	}
+	if part != max30102.PartID {
+		t.Errorf("Read() after timeout = %#x, want %#x", part, max30102.PartID)
+	}
+}
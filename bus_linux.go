@@ -0,0 +1,44 @@
+//go:build linux && !tinygo
+// +build linux,!tinygo
+
+package max3010x
+
+import (
+	"fmt"
+
+	"github.com/cgxeiji/max3010x/max30102"
+	"github.com/cgxeiji/serial"
+)
+
+// openBus opens the I²C bus set by OnBus, OnAddr and OnMux.
+func (d *Device) openBus() (max30102.Bus, error) {
+	addr := d.addr
+	if addr == 0 {
+		addr = maxAddr
+	}
+	i2c, err := serial.NewI2C(d.bus, addr)
+	if err != nil {
+		return nil, fmt.Errorf("max3010x: could not initialize I2C: %w", err)
+	}
+	if d.muxAddr == 0 {
+		return i2c, nil
+	}
+
+	bus, err := d.onMux(i2c)
+	if err != nil {
+		i2c.Close()
+		return nil, err
+	}
+
+	return bus, nil
+}
+
+func (d *Device) onMux(bus max30102.Bus) (max30102.Bus, error) {
+	m, err := max30102.OpenMux(d.bus, d.muxAddr)
+	if err != nil {
+		return nil, fmt.Errorf("max3010x: could not open multiplexer: %w", err)
+	}
+	defer m.Close()
+
+	return m.Bus(d.muxCh, bus)
+}
@@ -0,0 +1,21 @@
+//go:build !linux || tinygo
+// +build !linux tinygo
+
+package max3010x
+
+import (
+	"errors"
+
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+// openBus is only supported on Linux. Use WithBus or WithSensor on other
+// platforms.
+func (d *Device) openBus() (max30102.Bus, error) {
+	return nil, errors.New("max3010x: I2C buses can only be opened on Linux, use WithBus instead")
+}
+
+// Scan is only supported on Linux.
+func Scan() ([]Found, error) {
+	return nil, errors.New("max3010x: I2C buses can only be scanned on Linux")
+}
@@ -0,0 +1,44 @@
+package emulator
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock provides the emulator with the passage of time.
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// ManualClock is a Clock that only moves forward when told to.
+type ManualClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewManualClock returns a new ManualClock starting at t.
+func NewManualClock(t time.Time) *ManualClock {
+	return &ManualClock{
+		now: t,
+	}
+}
+
+// Now returns the current time of the clock.
+func (c *ManualClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance moves the clock forward by d.
+func (c *ManualClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
@@ -0,0 +1,401 @@
+// Package emulator provides an in-memory MAX30102 that can be used as a
+// max30102.Bus to run the drivers without hardware.
+package emulator
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+var (
+	// ErrClosed is returned when accessing an emulator that has been closed.
+	ErrClosed = errors.New("emulator: bus is closed")
+)
+
+// RefAmplitude is the LED pulse amplitude register value (2.8mA) at which
+// the values returned by a Waveform are emitted unscaled.
+const RefAmplitude = 0x0E
+
+const (
+	fifoDepth = 32
+	adcMax    = (1 << 18) - 1
+	// tempConversion is the time it takes to measure the die temperature.
+	tempConversion = 29 * time.Millisecond
+	// maxBacklog limits the amount of samples generated at once when the
+	// clock jumps forward. Older samples are counted as overflows.
+	maxBacklog = 2 * fifoDepth
+
+	rolloverEna byte = (1 << 4)
+	shutdown    byte = (1 << 7)
+	modeBits    byte = 0b111
+)
+
+const (
+	ledRed = iota + 1
+	ledIR
+	ledGreen
+)
+
+var (
+	sampleRates = [...]float64{50, 100, 200, 400, 800, 1000, 1600, 3200}
+	averages    = [...]int{1, 2, 4, 8, 16, 32, 32, 32}
+	adcRanges   = [...]float64{2048, 4096, 8192, 16384}
+)
+
+// Emulator emulates a MAX30102 at the register level. It models the FIFO,
+// interrupt flags, temperature conversion and LED amplitude registers, and
+// produces samples from a Waveform at the configured sample rate, as measured
+// by its Clock. An Emulator is safe for concurrent use.
+type Emulator struct {
+	mu sync.Mutex
+
+	regs [256]byte
+
+	fifo    [fifoDepth][]byte
+	full    bool
+	pending []byte
+
+	clock    Clock
+	waveform Waveform
+	start    time.Time
+	next     time.Time
+
+	temp   float64
+	tempAt time.Time
+
+	// intLow is the level of the INT pin, and edges counts its falling
+	// edges.
+	intLow bool
+	edges  int
+
+	rev    byte
+	closed bool
+}
+
+// New returns a new powered up emulator that samples w.
+func New(w Waveform, options ...Option) *Emulator {
+	e := &Emulator{
+		waveform: w,
+		temp:     25,
+		rev:      0x03,
+	}
+	WithClock(realClock{})(e)
+	for _, opt := range options {
+		opt(e)
+	}
+	e.powerOn()
+
+	return e
+}
+
+// PowerCycle simulates a brownout of the device. All registers revert to
+// their power-on state and the PowerReady flag is raised.
+func (e *Emulator) PowerCycle() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.powerOn()
+	e.irq()
+}
+
+// Read reads a single byte from a register.
+func (e *Emulator) Read(reg byte) (byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return 0, ErrClosed
+	}
+	e.update()
+	defer e.irq()
+
+	return e.read(reg), nil
+}
+
+// ReadBytes reads n bytes starting from a register. The register address
+// auto-increments, except for the FIFO data register which returns
+// consecutive bytes of the FIFO.
+func (e *Emulator) ReadBytes(reg byte, n int) ([]byte, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return nil, ErrClosed
+	}
+	e.update()
+	defer e.irq()
+
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = e.read(reg)
+		if reg != max30102.FIFOData {
+			reg++
+		}
+	}
+
+	return b, nil
+}
+
+// Write writes a byte to a register.
+func (e *Emulator) Write(reg, data byte) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return ErrClosed
+	}
+	e.update()
+	defer e.irq()
+
+	switch reg {
+	case max30102.IntStat1, max30102.IntStat2, max30102.FIFOData,
+		max30102.TempInt, max30102.TempFrac,
+		max30102.RegRevID, max30102.RegPartID:
+		// read-only
+	case max30102.ModeCfg:
+		if data&max30102.ResetControl != 0 {
+			e.reset()
+			return nil
+		}
+		e.regs[reg] = data
+	case max30102.TempCfg:
+		e.regs[reg] = data & max30102.TempEna
+		if data&max30102.TempEna != 0 {
+			e.tempAt = e.clock.Now().Add(tempConversion)
+		}
+	case max30102.FIFOWrPtr, max30102.FIFORdPtr:
+		e.regs[reg] = data & (fifoDepth - 1)
+		e.full = false
+		e.pending = nil
+	case max30102.OvfCount:
+		e.regs[reg] = data & (fifoDepth - 1)
+	default:
+		e.regs[reg] = data
+	}
+
+	return nil
+}
+
+// Close closes the bus. Any further access returns ErrClosed.
+func (e *Emulator) Close() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.closed = true
+}
+
+func (e *Emulator) powerOn() {
+	e.reset()
+	e.regs[max30102.IntStat1] = max30102.PowerReady
+}
+
+func (e *Emulator) reset() {
+	e.regs = [256]byte{}
+	e.regs[max30102.RegRevID] = e.rev
+	e.regs[max30102.RegPartID] = max30102.PartID
+	e.full = false
+	e.pending = nil
+	e.tempAt = time.Time{}
+	e.next = e.clock.Now()
+}
+
+func (e *Emulator) read(reg byte) byte {
+	b := e.regs[reg]
+
+	switch reg {
+	case max30102.IntStat1, max30102.IntStat2:
+		e.regs[reg] = 0
+	case max30102.FIFOData:
+		// As on the device, AlmostFull is only cleared by reading the
+		// status.
+		e.regs[max30102.IntStat1] &^= max30102.NewFIFOData
+		if len(e.pending) == 0 && !e.pop() {
+			return 0
+		}
+		b = e.pending[0]
+		e.pending = e.pending[1:]
+	}
+
+	return b
+}
+
+// update brings the state of the emulator up to the current time.
+func (e *Emulator) update() {
+	defer e.irq()
+	now := e.clock.Now()
+
+	if !e.tempAt.IsZero() && !now.Before(e.tempAt) {
+		e.tempAt = time.Time{}
+		e.convertTemp()
+	}
+
+	channels := e.channels()
+	if e.regs[max30102.ModeCfg]&shutdown != 0 || len(channels) == 0 {
+		e.next = now
+		return
+	}
+
+	avg := averages[e.regs[max30102.FIFOCfg]>>5&0b111]
+	rate := sampleRates[e.regs[max30102.SpO2Cfg]>>2&0b111] / float64(avg)
+	period := time.Duration(float64(time.Second) / rate)
+
+	if n := int(now.Sub(e.next) / period); n > maxBacklog {
+		skip := n - maxBacklog
+		e.next = e.next.Add(time.Duration(skip) * period)
+		for i := 0; i < skip; i++ {
+			e.overflow()
+		}
+	}
+	for !e.next.After(now) {
+		e.push(e.sample(channels, e.next.Sub(e.start)))
+		e.next = e.next.Add(period)
+	}
+}
+
+// irq updates the level of the INT pin, which is pulled low while an
+// interrupt flag is set, and counts its falling edges.
+func (e *Emulator) irq() {
+	low := e.regs[max30102.IntStat1]|e.regs[max30102.IntStat2] != 0
+	if low && !e.intLow {
+		e.edges++
+	}
+	e.intLow = low
+}
+
+func (e *Emulator) convertTemp() {
+	i, f := math.Modf(e.temp)
+	if f < 0 {
+		i--
+		f++
+	}
+	e.regs[max30102.TempInt] = byte(int8(i))
+	e.regs[max30102.TempFrac] = byte(f/0.0625) & 0x0F
+	e.regs[max30102.TempCfg] &^= max30102.TempEna
+	if e.regs[max30102.IntEna2]&max30102.DieTempReady != 0 {
+		e.regs[max30102.IntStat2] |= max30102.DieTempReady
+	}
+}
+
+// channels returns the LEDs sampled in each FIFO slot for the current mode.
+func (e *Emulator) channels() []int {
+	switch e.regs[max30102.ModeCfg] & modeBits {
+	case max30102.ModeHR:
+		return []int{ledRed}
+	case max30102.ModeSpO2:
+		return []int{ledRed, ledIR}
+	case max30102.ModeMultiLed:
+		slots := []byte{
+			e.regs[max30102.MultiLedModeS2S1] & 0b111,
+			e.regs[max30102.MultiLedModeS2S1] >> 4 & 0b111,
+			e.regs[max30102.MultiLedModeS4S3] & 0b111,
+			e.regs[max30102.MultiLedModeS4S3] >> 4 & 0b111,
+		}
+		var c []int
+		for _, s := range slots {
+			if s == 0 {
+				break
+			}
+			// Slots 5 to 7 drive the same LEDs in pilot mode.
+			c = append(c, int(s&0b11))
+		}
+		return c
+	}
+
+	return nil
+}
+
+func (e *Emulator) sample(channels []int, t time.Duration) []byte {
+	red, ir := e.waveform.Sample(t)
+	green := 0.0
+	if g, ok := e.waveform.(GreenWaveform); ok {
+		green = g.Green(t)
+	}
+	adcRange := adcRanges[e.regs[max30102.SpO2Cfg]>>5&0b11]
+	bits := 15 + uint(e.regs[max30102.SpO2Cfg]&0b11)
+
+	b := make([]byte, 0, 3*len(channels))
+	for _, led := range channels {
+		var v float64
+		var amp byte
+		switch led {
+		case ledRed:
+			v, amp = red, e.regs[max30102.Led1PA]
+		case ledIR:
+			v, amp = ir, e.regs[max30102.Led2PA]
+		case ledGreen:
+			v, amp = green, e.regs[max30102.Led3PA]
+		}
+		v *= float64(amp) / RefAmplitude
+		v *= adcRanges[0] / adcRange
+		if v < 0 {
+			v = 0
+		}
+		if v > 1 {
+			v = 1
+		}
+		counts := uint32(v * adcMax)
+		counts &^= (1 << (18 - bits)) - 1
+
+		b = append(b, byte(counts>>16&0b11), byte(counts>>8), byte(counts))
+	}
+
+	return b
+}
+
+func (e *Emulator) push(sample []byte) {
+	wr := e.regs[max30102.FIFOWrPtr]
+	rd := e.regs[max30102.FIFORdPtr]
+
+	if e.full {
+		e.overflow()
+		if e.regs[max30102.FIFOCfg]&rolloverEna == 0 {
+			return
+		}
+		e.regs[max30102.FIFORdPtr] = (rd + 1) % fifoDepth
+	}
+
+	e.fifo[wr] = sample
+	wr = (wr + 1) % fifoDepth
+	e.regs[max30102.FIFOWrPtr] = wr
+	e.full = wr == e.regs[max30102.FIFORdPtr]
+
+	if e.regs[max30102.IntEna1]&max30102.NewFIFOData != 0 {
+		e.regs[max30102.IntStat1] |= max30102.NewFIFOData
+	}
+	left := int(e.regs[max30102.FIFOCfg] & 0x0F)
+	if e.available() == fifoDepth-left &&
+		e.regs[max30102.IntEna1]&max30102.AlmostFull != 0 {
+		e.regs[max30102.IntStat1] |= max30102.AlmostFull
+	}
+}
+
+func (e *Emulator) pop() bool {
+	if e.available() == 0 {
+		return false
+	}
+	rd := e.regs[max30102.FIFORdPtr]
+	e.pending = append(e.pending[:0], e.fifo[rd]...)
+	e.regs[max30102.FIFORdPtr] = (rd + 1) % fifoDepth
+	e.full = false
+	// As on the device, popping a sample resets the overflow counter.
+	e.regs[max30102.OvfCount] = 0
+
+	return len(e.pending) > 0
+}
+
+func (e *Emulator) available() int {
+	if e.full {
+		return fifoDepth
+	}
+	wr := int(e.regs[max30102.FIFOWrPtr])
+	rd := int(e.regs[max30102.FIFORdPtr])
+	return (wr + fifoDepth - rd) % fifoDepth
+}
+
+func (e *Emulator) overflow() {
+	if e.regs[max30102.OvfCount] < fifoDepth-1 {
+		e.regs[max30102.OvfCount]++
+	}
+}
@@ -0,0 +1,68 @@
+package emulator
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+// pinPoll is the time between two checks of the INT pin.
+const pinPoll = time.Millisecond
+
+// pin is the INT pin of an emulator.
+type pin struct {
+	e    *Emulator
+	seen int
+
+	once sync.Once
+	done chan struct{}
+}
+
+// Interrupt returns the INT pin of the emulator, to be used with
+// max30102.InterruptPin. As on the device, the pin stays low while any
+// interrupt flag is set, so a falling edge is only seen after the interrupt
+// status registers are read.
+func (e *Emulator) Interrupt() max30102.Interrupt {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	return &pin{
+		e:    e,
+		seen: e.edges,
+		done: make(chan struct{}),
+	}
+}
+
+// Wait blocks until a falling edge is detected on the INT pin since the last
+// call.
+func (p *pin) Wait() error {
+	for {
+		p.e.mu.Lock()
+		if p.e.closed {
+			p.e.mu.Unlock()
+			return ErrClosed
+		}
+		p.e.update()
+		edges := p.e.edges
+		p.e.mu.Unlock()
+
+		if edges != p.seen {
+			p.seen = edges
+			return nil
+		}
+
+		select {
+		case <-time.After(pinPoll):
+		case <-p.done:
+			return ErrClosed
+		}
+	}
+}
+
+// Close releases the pin. Pending and further calls to Wait return
+// ErrClosed.
+func (p *pin) Close() error {
+	p.once.Do(func() { close(p.done) })
+	return nil
+}
@@ -0,0 +1,60 @@
+package emulator
+
+import "github.com/cgxeiji/max3010x/max30102"
+
+// Option defines a functional option for the emulator.
+type Option func(e *Emulator) Option
+
+// Options sets different configuration options and returns the previous value
+// of the last option passed.
+func (e *Emulator) Options(options ...Option) Option {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	var old Option
+	for _, opt := range options {
+		old = opt(e)
+	}
+
+	return old
+}
+
+// WithClock sets the clock that drives the sampling of the emulator. By
+// default, the wall clock is used.
+func WithClock(c Clock) Option {
+	return func(e *Emulator) Option {
+		old := e.clock
+		e.clock = c
+		e.start = c.Now()
+		e.next = e.start
+		return WithClock(old)
+	}
+}
+
+// WithWaveform sets the signal that the emulator samples.
+func WithWaveform(w Waveform) Option {
+	return func(e *Emulator) Option {
+		old := e.waveform
+		e.waveform = w
+		return WithWaveform(old)
+	}
+}
+
+// Temperature sets the die temperature reported by the emulator in Celsius.
+func Temperature(celsius float64) Option {
+	return func(e *Emulator) Option {
+		old := e.temp
+		e.temp = celsius
+		return Temperature(old)
+	}
+}
+
+// RevID sets the revision ID reported by the emulator.
+func RevID(rev byte) Option {
+	return func(e *Emulator) Option {
+		old := e.rev
+		e.rev = rev
+		e.regs[max30102.RegRevID] = rev
+		return RevID(old)
+	}
+}
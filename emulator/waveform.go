@@ -0,0 +1,90 @@
+package emulator
+
+import (
+	"math"
+	"time"
+)
+
+// Waveform defines the optical signal seen by the photodiode of the emulator.
+type Waveform interface {
+	// Sample returns the normalized (0.0 - 1.0) red and IR readings at time t
+	// since the emulator was powered up, as seen with the LEDs driven at
+	// RefAmplitude and the ADC set to its lowest range.
+	Sample(t time.Duration) (red, ir float64)
+}
+
+// GreenWaveform is implemented by waveforms that also provide the signal of a
+// green LED (MAX30101 and MAX30105). Green channels of waveforms that do not
+// implement it read 0.
+type GreenWaveform interface {
+	Waveform
+	// Green returns the normalized (0.0 - 1.0) green reading at time t, with
+	// the same conventions as Sample.
+	Green(t time.Duration) float64
+}
+
+// WaveformFunc is an adapter to use ordinary functions as a Waveform.
+type WaveformFunc func(t time.Duration) (red, ir float64)
+
+// Sample calls f(t).
+func (f WaveformFunc) Sample(t time.Duration) (red, ir float64) {
+	return f(t)
+}
+
+// Constant returns a waveform with fixed red and IR values.
+func Constant(red, ir float64) Waveform {
+	return WaveformFunc(func(time.Duration) (float64, float64) {
+		return red, ir
+	})
+}
+
+// Pulse returns a waveform that resembles a finger with a heart rate of bpm
+// beats per minute and an oxygen saturation of spo2 percent. The ratio
+// between the red and IR pulsatile components follows the same empirical
+// calibration used by max3010x.Device.SpO2. The waveform also provides a green
+// signal with a stronger pulsatile component.
+func Pulse(bpm, spo2 float64) GreenWaveform {
+	const (
+		irDC    = 0.50
+		irAC    = 0.002
+		redDC   = 0.45
+		greenDC = 0.30
+		greenAC = 0.006
+	)
+	r := (104 - spo2) / 17
+
+	return &pulse{
+		freq:    bpm / 60,
+		redDC:   redDC,
+		redAC:   r * (irAC / irDC) * redDC,
+		irDC:    irDC,
+		irAC:    irAC,
+		greenDC: greenDC,
+		greenAC: greenAC,
+	}
+}
+
+type pulse struct {
+	freq float64
+
+	redDC, redAC     float64
+	irDC, irAC       float64
+	greenDC, greenAC float64
+}
+
+// shape returns the pulsatile component at time t, normalized from -1.0 to
+// 1.0.
+func (p *pulse) shape(t time.Duration) float64 {
+	phase := 2 * math.Pi * p.freq * t.Seconds()
+	// A systolic peak followed by a smaller dicrotic wave.
+	return (math.Sin(phase) + 0.3*math.Sin(2*phase)) / 1.3
+}
+
+func (p *pulse) Sample(t time.Duration) (red, ir float64) {
+	s := p.shape(t)
+	return p.redDC - p.redAC*s, p.irDC - p.irAC*s
+}
+
+func (p *pulse) Green(t time.Duration) float64 {
+	return p.greenDC - p.greenAC*p.shape(t)
+}
@@ -0,0 +1,181 @@
+// Package fault wraps a max30102.Bus to inject faults, such as missing
+// acknowledgements, random read errors, stuck bits, slow responses and device
+// resets, in order to test how the drivers behave on unreliable hardware.
+package fault
+
+import (
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+var (
+	// ErrNACK is returned by transactions on a register set with NACK.
+	ErrNACK = errors.New("fault: device did not acknowledge")
+	// ErrRead is returned by reads failed by ReadErrors.
+	ErrRead = errors.New("fault: read error")
+)
+
+type stuck struct {
+	mask  byte
+	value byte
+}
+
+// Bus is a max30102.Bus that injects faults in the transactions made through
+// another bus. A Bus is safe for concurrent use.
+type Bus struct {
+	mu  sync.Mutex
+	bus max30102.Bus
+
+	nack     map[byte]bool
+	stuck    map[byte]stuck
+	readRate float64
+	rng      *rand.Rand
+	delay    time.Duration
+
+	resetAfter int
+	count      int
+}
+
+// Wrap returns a new bus that injects faults into the transactions made
+// through bus. Closing the returned bus closes bus.
+func Wrap(bus max30102.Bus, faults ...Fault) *Bus {
+	b := &Bus{
+		bus:   bus,
+		nack:  make(map[byte]bool),
+		stuck: make(map[byte]stuck),
+		rng:   rand.New(rand.NewSource(1)),
+	}
+
+	for _, f := range faults {
+		f(b)
+	}
+
+	return b
+}
+
+// Faults sets different faults and returns the previous value of the last
+// fault passed. Faults can be changed while the bus is in use.
+func (b *Bus) Faults(faults ...Fault) Fault {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	var old Fault
+	for _, f := range faults {
+		old = f(b)
+	}
+
+	return old
+}
+
+// Count returns the number of transactions made through the bus.
+func (b *Bus) Count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.count
+}
+
+// before is called before every transaction on reg. It returns the error to
+// inject, if any.
+func (b *Bus) before(reg byte, read bool) error {
+	b.count++
+	if b.delay > 0 {
+		time.Sleep(b.delay)
+	}
+
+	if b.resetAfter > 0 && b.count == b.resetAfter {
+		b.reset()
+	}
+
+	if b.nack[reg] {
+		return ErrNACK
+	}
+	if read && b.readRate > 0 && b.rng.Float64() < b.readRate {
+		return ErrRead
+	}
+
+	return nil
+}
+
+// reset simulates a reset of the device. Buses that can simulate a power
+// loss, such as the emulator, are power cycled. Otherwise, a soft reset is
+// issued.
+func (b *Bus) reset() {
+	if p, ok := b.bus.(interface{ PowerCycle() }); ok {
+		p.PowerCycle()
+		return
+	}
+	b.bus.Write(max30102.ModeCfg, max30102.ResetControl)
+}
+
+func (b *Bus) apply(reg, v byte) byte {
+	if s, ok := b.stuck[reg]; ok {
+		v = v&^s.mask | s.value&s.mask
+	}
+	return v
+}
+
+// Read reads a single byte from a register.
+func (b *Bus) Read(reg byte) (byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.before(reg, true); err != nil {
+		return 0, err
+	}
+
+	v, err := b.bus.Read(reg)
+	if err != nil {
+		return 0, err
+	}
+
+	return b.apply(reg, v), nil
+}
+
+// ReadBytes reads n bytes starting from a register. Stuck bits are applied
+// to the bytes read from reg only.
+func (b *Bus) ReadBytes(reg byte, n int) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.before(reg, true); err != nil {
+		return nil, err
+	}
+
+	v, err := b.bus.ReadBytes(reg, n)
+	if err != nil {
+		return nil, err
+	}
+	if reg == max30102.FIFOData {
+		for i := range v {
+			v[i] = b.apply(reg, v[i])
+		}
+	} else if len(v) > 0 {
+		v[0] = b.apply(reg, v[0])
+	}
+
+	return v, nil
+}
+
+// Write writes a byte to a register.
+func (b *Bus) Write(reg, data byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err := b.before(reg, false); err != nil {
+		return err
+	}
+
+	return b.bus.Write(reg, data)
+}
+
+// Close closes the wrapped bus.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.bus.Close()
+}
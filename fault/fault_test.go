@@ -0,0 +1,78 @@
+package fault_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cgxeiji/max3010x/emulator"
+	"github.com/cgxeiji/max3010x/fault"
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+func TestFaults(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault fault.Fault
+		reg   byte
+		want  byte
+		err   error
+	}{
+		{"none", nil, max30102.RegPartID, max30102.PartID, nil},
+		{"NACK", fault.NACK(max30102.RegPartID), max30102.RegPartID, 0, fault.ErrNACK},
+		{"NACK other register", fault.NACK(max30102.RegRevID), max30102.RegPartID, max30102.PartID, nil},
+		{"read errors", fault.ReadErrors(1, 1), max30102.RegPartID, 0, fault.ErrRead},
+		{"no read errors", fault.ReadErrors(0, 1), max30102.RegPartID, max30102.PartID, nil},
+		{"stuck bits", fault.StuckBits(max30102.RegPartID, 0xF0, 0xA0), max30102.RegPartID, 0xA5, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := fault.Wrap(emulator.New(emulator.Constant(0, 0)))
+			defer bus.Close()
+
+			var undo fault.Fault
+			if tt.fault != nil {
+				undo = bus.Faults(tt.fault)
+			}
+
+			got, err := bus.Read(tt.reg)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("Read(%#x) error = %v, want %v", tt.reg, err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("Read(%#x) = %#x, want %#x", tt.reg, got, tt.want)
+			}
+
+			if undo == nil {
+				return
+			}
+			bus.Faults(undo)
+			if got, err := bus.Read(tt.reg); err != nil || got != max30102.PartID {
+				t.Errorf("Read(%#x) after undo = %#x, %v, want %#x, nil", tt.reg, got, err, max30102.PartID)
+			}
+		})
+	}
+}
+
+func TestResetAfter(t *testing.T) {
+	bus := fault.Wrap(emulator.New(emulator.Constant(0, 0)))
+	defer bus.Close()
+
+	if err := bus.Write(max30102.Led1PA, 0x24); err != nil {
+		t.Fatal(err)
+	}
+	bus.Faults(fault.ResetAfter(2))
+
+	for i, want := range []byte{0x24, 0} {
+		got, err := bus.Read(max30102.Led1PA)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("read %d = %#x, want %#x", i, got, want)
+		}
+	}
+	if n := bus.Count(); n != 3 {
+		t.Errorf("Count() = %d, want 3", n)
+	}
+}
@@ -0,0 +1,89 @@
+package fault
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Fault defines a functional option that configures a fault.
+type Fault func(b *Bus) Fault
+
+// NACK makes every transaction on reg fail with ErrNACK. Use NoNACK to
+// disable it.
+func NACK(reg byte) Fault {
+	return func(b *Bus) Fault {
+		old := b.nack[reg]
+		b.nack[reg] = true
+		if !old {
+			return NoNACK(reg)
+		}
+		return NACK(reg)
+	}
+}
+
+// NoNACK disables the NACK fault on reg.
+func NoNACK(reg byte) Fault {
+	return func(b *Bus) Fault {
+		old := b.nack[reg]
+		delete(b.nack, reg)
+		if old {
+			return NACK(reg)
+		}
+		return NoNACK(reg)
+	}
+}
+
+// ReadErrors makes reads fail with ErrRead at random, with a probability of
+// rate (0.0 to 1.0). The random sequence is determined by seed, so that
+// failures can be reproduced.
+func ReadErrors(rate float64, seed int64) Fault {
+	return func(b *Bus) Fault {
+		old := b.readRate
+		b.readRate = rate
+		b.rng = rand.New(rand.NewSource(seed))
+		return ReadErrors(old, seed)
+	}
+}
+
+// StuckBits forces the bits in mask of every byte read from reg to value.
+// A mask of 0 disables the fault.
+func StuckBits(reg, mask, value byte) Fault {
+	return func(b *Bus) Fault {
+		old, ok := b.stuck[reg]
+		if mask == 0 {
+			delete(b.stuck, reg)
+		} else {
+			b.stuck[reg] = stuck{mask: mask, value: value}
+		}
+		if !ok {
+			return StuckBits(reg, 0, 0)
+		}
+		return StuckBits(reg, old.mask, old.value)
+	}
+}
+
+// Delay delays every transaction by d.
+func Delay(d time.Duration) Fault {
+	return func(b *Bus) Fault {
+		old := b.delay
+		b.delay = d
+		return Delay(old)
+	}
+}
+
+// ResetAfter resets the device once, right before the nth transaction counted
+// from now, simulating a brownout in the middle of a session. An n of 0
+// disables the fault.
+func ResetAfter(n int) Fault {
+	return func(b *Bus) Fault {
+		old := 0
+		if b.resetAfter > b.count {
+			old = b.resetAfter - b.count
+		}
+		b.resetAfter = 0
+		if n > 0 {
+			b.resetAfter = b.count + n
+		}
+		return ResetAfter(old)
+	}
+}
@@ -2,4 +2,7 @@ module github.com/cgxeiji/max3010x
 
 go 1.15
 
-require github.com/cgxeiji/serial v0.1.1
+require (
+	github.com/cgxeiji/serial v0.1.1
+	periph.io/x/periph v3.6.7+incompatible
+)
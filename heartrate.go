@@ -24,10 +24,19 @@ func (d *Device) HeartRate() (float64, error) {
 	defer cancel()
 
 	go func(ctx context.Context) {
+		// HeartRate stops listening when ctx is done, so never block on a
+		// send after that.
+		send := func(b beatPkg) {
+			select {
+			case beatCh <- b:
+			case <-ctx.Done():
+			}
+		}
+
 		if err := d.detectBeat(ctx); err != nil {
-			beatCh <- beatPkg{
+			send(beatPkg{
 				err: err,
-			}
+			})
 			return
 		}
 		timer := time.Now()
@@ -35,16 +44,14 @@ func (d *Device) HeartRate() (float64, error) {
 		for {
 			select {
 			case <-ctx.Done():
-				beatCh <- beatPkg{
-					err: ctx.Err(),
-				}
+				return
 			default:
 			}
 
 			if err := d.detectBeat(ctx); err != nil {
-				beatCh <- beatPkg{
+				send(beatPkg{
 					err: err,
-				}
+				})
 				return
 			}
 			t := time.Since(timer)
@@ -56,10 +63,10 @@ func (d *Device) HeartRate() (float64, error) {
 				continue // invalid
 			}
 
-			beatCh <- beatPkg{
+			send(beatPkg{
 				span: float64(t.Milliseconds()),
-			}
-			break
+			})
+			return
 		}
 	}(ctx)
 
@@ -0,0 +1,389 @@
+// Package iio implements a MAX3010x sensor backed by the Linux kernel IIO
+// driver, for boards where the kernel already owns the chip. Single readings
+// are taken from sysfs and batches from the buffer character device.
+package iio
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cgxeiji/max3010x/max30100"
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+var (
+	// ErrNotFound is returned when no IIO device with a supported driver is
+	// found.
+	ErrNotFound = errors.New("iio: no MAX3010x device found")
+	// ErrNotSupported is returned by functions that cannot be performed while
+	// the kernel owns the device.
+	ErrNotSupported = errors.New("iio: not supported by the kernel driver")
+)
+
+// drivers maps the names of the kernel drivers to their part ID and ADC
+// resolution.
+var drivers = map[string]struct {
+	partID byte
+	bits   uint
+}{
+	"max30100": {max30100.PartID, 16},
+	"max30102": {max30102.PartID, 18},
+	"max30105": {max30102.PartID, 18},
+}
+
+const (
+	chanIR  = "in_intensity_ir"
+	chanRed = "in_intensity_red"
+)
+
+// channel defines a channel enabled in the buffer.
+type channel struct {
+	name   string
+	index  int
+	typ    scanType
+	offset int
+}
+
+// Device defines a MAX3010x device handled by the kernel IIO driver.
+type Device struct {
+	sysfsRoot string
+	devRoot   string
+	id        string
+	batch     int
+
+	path   string
+	name   string
+	maxADC float64
+
+	buf      *os.File
+	channels []channel
+	scanSize int
+}
+
+// New returns a new device handled by the kernel IIO driver.
+func New(options ...Option) (*Device, error) {
+	d := &Device{
+		sysfsRoot: "/sys/bus/iio/devices",
+		devRoot:   "/dev",
+		batch:     32,
+	}
+
+	for _, option := range options {
+		option(d)
+	}
+
+	if err := d.find(); err != nil {
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func (d *Device) find() error {
+	ids := []string{d.id}
+	if d.id == "" {
+		matches, err := filepath.Glob(filepath.Join(d.sysfsRoot, "iio:device*"))
+		if err != nil {
+			return fmt.Errorf("iio: could not list devices: %w", err)
+		}
+		sort.Strings(matches)
+		ids = ids[:0]
+		for _, m := range matches {
+			ids = append(ids, filepath.Base(m))
+		}
+	}
+
+	for _, id := range ids {
+		path := filepath.Join(d.sysfsRoot, id)
+		name, err := readString(filepath.Join(path, "name"))
+		if err != nil {
+			continue
+		}
+		drv, ok := drivers[name]
+		if !ok {
+			continue
+		}
+
+		d.id = id
+		d.path = path
+		d.name = name
+		d.maxADC = float64(uint64(1)<<drv.bits - 1)
+		return nil
+	}
+
+	return ErrNotFound
+}
+
+// Name returns the name of the kernel driver (e.g. "max30102").
+func (d *Device) Name() string {
+	return d.name
+}
+
+// PartID returns the part ID of the device, based on the kernel driver.
+func (d *Device) PartID() (byte, error) {
+	return drivers[d.name].partID, nil
+}
+
+// RevID returns the revision ID of the device. The kernel does not expose the
+// revision, so it always returns 0.
+func (d *Device) RevID() (byte, error) {
+	return 0, nil
+}
+
+// Reset does nothing, as the kernel driver owns the configuration of the
+// device.
+func (d *Device) Reset() error {
+	return nil
+}
+
+// Calibrate returns ErrNotSupported, as the LED currents are set by the
+// kernel driver (e.g. from the device tree).
+func (d *Device) Calibrate() error {
+	return ErrNotSupported
+}
+
+// Temperature returns the current temperature of the device.
+func (d *Device) Temperature() (float64, error) {
+	raw, err := d.readFloat("in_temp_raw")
+	if err != nil {
+		return 0, fmt.Errorf("iio: could not read temperature: %w", err)
+	}
+	offset, err := d.readFloat("in_temp_offset")
+	if err != nil {
+		offset = 0
+	}
+	scale, err := d.readFloat("in_temp_scale")
+	if err != nil {
+		scale = 1000
+	}
+
+	// The scale is in milli degrees Celsius.
+	return (raw + offset) * scale / 1000, nil
+}
+
+// IRRed returns the value of the red LED and IR LED. The values are normalized
+// from 0.0 to 1.0. While the buffer is enabled by IRRedBatch, the values are
+// read from the buffer.
+func (d *Device) IRRed() (ir, red float64, err error) {
+	if d.buf != nil {
+		irs, reds, err := d.read(1)
+		if err != nil {
+			return 0, 0, err
+		}
+		return irs[0], reds[0], nil
+	}
+
+	rawIR, err := d.readFloat(chanIR + "_raw")
+	if err != nil {
+		return 0, 0, fmt.Errorf("iio: could not read IR LED: %w", err)
+	}
+	rawRed, err := d.readFloat(chanRed + "_raw")
+	if err != nil {
+		return 0, 0, fmt.Errorf("iio: could not read red LED: %w", err)
+	}
+
+	return rawIR / d.maxADC, rawRed / d.maxADC, nil
+}
+
+// IRRedBatch returns a batch of IR and red LED values read from the buffer
+// character device. The buffer is enabled on the first call and stays enabled
+// until Shutdown or Close is called.
+func (d *Device) IRRedBatch() (ir, red []float64, err error) {
+	if d.buf == nil {
+		if err := d.enable(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return d.read(d.batch)
+}
+
+// Shutdown disables the buffer, which lets the kernel driver power down the
+// device.
+func (d *Device) Shutdown() error {
+	if d.buf == nil {
+		return nil
+	}
+
+	d.buf.Close()
+	d.buf = nil
+	if err := d.writeString("buffer/enable", "0"); err != nil {
+		return fmt.Errorf("iio: could not disable buffer: %w", err)
+	}
+
+	return nil
+}
+
+// Startup does nothing, as the buffer is enabled on demand by IRRedBatch.
+func (d *Device) Startup() error {
+	return nil
+}
+
+// Close closes the device and cleans after itself.
+func (d *Device) Close() {
+	d.Shutdown()
+}
+
+func (d *Device) enable() error {
+	if err := d.scanElements(); err != nil {
+		return err
+	}
+
+	if err := d.writeString("buffer/length", strconv.Itoa(2*d.batch)); err != nil {
+		return fmt.Errorf("iio: could not set buffer length: %w", err)
+	}
+	if err := d.writeString("buffer/enable", "1"); err != nil {
+		return fmt.Errorf("iio: could not enable buffer: %w", err)
+	}
+
+	f, err := os.Open(filepath.Join(d.devRoot, d.id))
+	if err != nil {
+		d.writeString("buffer/enable", "0")
+		return fmt.Errorf("iio: could not open buffer: %w", err)
+	}
+	d.buf = f
+
+	return nil
+}
+
+// scanElements enables the IR and red channels in the buffer, disables the
+// other scan elements (e.g. the timestamp), and computes the layout of a scan
+// from the elements left enabled.
+func (d *Device) scanElements() error {
+	ens, err := filepath.Glob(filepath.Join(d.path, "scan_elements", "*_en"))
+	if err != nil {
+		return fmt.Errorf("iio: could not list scan elements: %w", err)
+	}
+
+	for _, en := range ens {
+		name := strings.TrimSuffix(filepath.Base(en), "_en")
+		switch name {
+		case chanIR, chanRed:
+			if err := d.writeString("scan_elements/"+name+"_en", "1"); err != nil {
+				return fmt.Errorf("iio: could not enable %s: %w", name, err)
+			}
+		default:
+			// An element that cannot be disabled is still part of the
+			// layout below.
+			d.writeString("scan_elements/"+name+"_en", "0")
+		}
+	}
+
+	d.channels = d.channels[:0]
+	for _, en := range ens {
+		if v, err := readString(en); err != nil || v != "1" {
+			continue
+		}
+		name := strings.TrimSuffix(filepath.Base(en), "_en")
+		index, err := d.readFloat("scan_elements/" + name + "_index")
+		if err != nil {
+			return fmt.Errorf("iio: could not get index of %s: %w", name, err)
+		}
+		typ, err := readString(filepath.Join(d.path, "scan_elements", name+"_type"))
+		if err != nil {
+			return fmt.Errorf("iio: could not get type of %s: %w", name, err)
+		}
+		t, err := parseScanType(typ)
+		if err != nil {
+			return err
+		}
+		d.channels = append(d.channels, channel{
+			name:  name,
+			index: int(index),
+			typ:   t,
+		})
+	}
+
+	found := 0
+	for _, c := range d.channels {
+		if c.name == chanIR || c.name == chanRed {
+			found++
+		}
+	}
+	if found != 2 {
+		return fmt.Errorf("iio: could not enable %s and %s: %w", chanIR, chanRed, ErrNotSupported)
+	}
+
+	d.scanSize = layout(d.channels)
+
+	return nil
+}
+
+// layout sorts the channels enabled in the buffer, sets their offset in a
+// scan and returns the size of a scan. Channels are stored in the order of
+// their index, each one aligned to its own size, and the scan is padded to
+// the largest one.
+func layout(channels []channel) int {
+	sort.Slice(channels, func(i, j int) bool {
+		return channels[i].index < channels[j].index
+	})
+
+	n, align := 0, 1
+	for i := range channels {
+		size := channels[i].typ.bytes()
+		if r := n % size; r != 0 {
+			n += size - r
+		}
+		channels[i].offset = n
+		n += size
+		if size > align {
+			align = size
+		}
+	}
+	if r := n % align; r != 0 {
+		n += align - r
+	}
+
+	return n
+}
+
+func (d *Device) read(n int) (ir, red []float64, err error) {
+	b := make([]byte, n*d.scanSize)
+	if _, err := io.ReadFull(d.buf, b); err != nil {
+		return nil, nil, fmt.Errorf("iio: could not read buffer: %w", err)
+	}
+
+	ir = make([]float64, n)
+	red = make([]float64, n)
+	for i := 0; i < n; i++ {
+		scan := b[i*d.scanSize:]
+		for _, c := range d.channels {
+			v := float64(c.typ.decode(scan[c.offset:])) / d.maxADC
+			switch c.name {
+			case chanIR:
+				ir[i] = v
+			case chanRed:
+				red[i] = v
+			}
+		}
+	}
+
+	return ir, red, nil
+}
+
+func (d *Device) readFloat(attr string) (float64, error) {
+	s, err := readString(filepath.Join(d.path, attr))
+	if err != nil {
+		return 0, err
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+func (d *Device) writeString(attr, s string) error {
+	return ioutil.WriteFile(filepath.Join(d.path, attr), []byte(s), 0)
+}
+
+func readString(path string) (string, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
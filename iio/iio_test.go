@@ -0,0 +1,208 @@
+package iio
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// element defines a scan element of a fake IIO device.
+type element struct {
+	name  string
+	index int
+	typ   string
+	en    bool
+}
+
+// fakeDevice creates a fake sysfs tree and buffer character device for a
+// max30102, and returns the options to use it.
+func fakeDevice(t *testing.T, elements []element, buffer []byte) []Option {
+	t.Helper()
+
+	root := t.TempDir()
+	sysfs := filepath.Join(root, "sys")
+	dev := filepath.Join(root, "dev")
+	path := filepath.Join(sysfs, "iio:device0")
+
+	files := map[string]string{
+		"name":          "max30102",
+		"buffer/length": "0",
+		"buffer/enable": "0",
+	}
+	for _, e := range elements {
+		en := "0"
+		if e.en {
+			en = "1"
+		}
+		files["scan_elements/"+e.name+"_en"] = en
+		files["scan_elements/"+e.name+"_index"] = itoa(e.index)
+		files["scan_elements/"+e.name+"_type"] = e.typ
+	}
+	for name, content := range files {
+		write(t, filepath.Join(path, name), content)
+	}
+	write(t, filepath.Join(dev, "iio:device0"), string(buffer))
+
+	return []Option{SysfsRoot(sysfs), DevRoot(dev)}
+}
+
+func write(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func itoa(i int) string {
+	return string(rune('0' + i))
+}
+
+// led encodes an 18-bit count as "be:u18/32>>8".
+func led(v uint32) []byte {
+	b := make([]byte, 4)
+	binary.BigEndian.PutUint32(b, v<<8)
+	return b
+}
+
+const ledType = "be:u18/32>>8"
+
+func TestIRRedBatchLayout(t *testing.T) {
+	const maxADC = 1<<18 - 1
+	ir := []uint32{1000, 2000}
+	red := []uint32{3000, 4000}
+
+	tests := []struct {
+		name     string
+		elements []element
+		scan     func(i int) []byte
+	}{
+		{
+			name: "IR and red",
+			elements: []element{
+				{chanIR, 1, ledType, false},
+				{chanRed, 0, ledType, false},
+			},
+			scan: func(i int) []byte {
+				return append(led(red[i]), led(ir[i])...)
+			},
+		},
+		{
+			name: "timestamp and green enabled",
+			elements: []element{
+				{chanRed, 0, ledType, true},
+				{chanIR, 1, ledType, true},
+				{"in_intensity_green", 2, ledType, true},
+				{"in_timestamp", 3, "le:s64/64>>0", true},
+			},
+			scan: func(i int) []byte {
+				return append(led(red[i]), led(ir[i])...)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buffer []byte
+			for i := range ir {
+				buffer = append(buffer, tt.scan(i)...)
+			}
+			opts := fakeDevice(t, tt.elements, buffer)
+
+			d, err := New(append(opts, BatchSize(len(ir)))...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+
+			gotIR, gotRed, err := d.IRRedBatch()
+			if err != nil {
+				t.Fatalf("IRRedBatch() = %v", err)
+			}
+			for i := range ir {
+				if want := float64(ir[i]) / maxADC; math.Abs(gotIR[i]-want) > 1e-9 {
+					t.Errorf("ir[%d] = %v, want %v", i, gotIR[i], want)
+				}
+				if want := float64(red[i]) / maxADC; math.Abs(gotRed[i]-want) > 1e-9 {
+					t.Errorf("red[%d] = %v, want %v", i, gotRed[i], want)
+				}
+			}
+
+			for _, e := range tt.elements {
+				want := "0"
+				if e.name == chanIR || e.name == chanRed {
+					want = "1"
+				}
+				got, err := readString(filepath.Join(d.path, "scan_elements", e.name+"_en"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != want {
+					t.Errorf("%s_en = %s, want %s", e.name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestLayout(t *testing.T) {
+	u16 := scanType{storageBits: 16}
+	u32 := scanType{storageBits: 32}
+	s64 := scanType{storageBits: 64, signed: true}
+
+	tests := []struct {
+		name     string
+		channels []channel
+		offsets  map[string]int
+		size     int
+	}{
+		{
+			name: "IR and red",
+			channels: []channel{
+				{name: chanIR, index: 1, typ: u32},
+				{name: chanRed, index: 0, typ: u32},
+			},
+			offsets: map[string]int{chanRed: 0, chanIR: 4},
+			size:    8,
+		},
+		{
+			name: "timestamp",
+			channels: []channel{
+				{name: chanRed, index: 0, typ: u32},
+				{name: chanIR, index: 1, typ: u32},
+				{name: "in_intensity_green", index: 2, typ: u32},
+				{name: "in_timestamp", index: 3, typ: s64},
+			},
+			offsets: map[string]int{chanRed: 0, chanIR: 4, "in_intensity_green": 8, "in_timestamp": 16},
+			size:    24,
+		},
+		{
+			name: "padded scan",
+			channels: []channel{
+				{name: chanIR, index: 0, typ: u32},
+				{name: chanRed, index: 1, typ: u16},
+			},
+			offsets: map[string]int{chanIR: 0, chanRed: 4},
+			size:    8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			size := layout(tt.channels)
+			if size != tt.size {
+				t.Errorf("size = %d, want %d", size, tt.size)
+			}
+			for _, c := range tt.channels {
+				if c.offset != tt.offsets[c.name] {
+					t.Errorf("offset of %s = %d, want %d", c.name, c.offset, tt.offsets[c.name])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package iio
+
+// Option configures a device.
+type Option func(d *Device) Option
+
+// SysfsRoot sets the directory where the IIO devices are listed. By default,
+// it is "/sys/bus/iio/devices".
+func SysfsRoot(path string) Option {
+	return func(d *Device) Option {
+		old := d.sysfsRoot
+		d.sysfsRoot = path
+		return SysfsRoot(old)
+	}
+}
+
+// DevRoot sets the directory where the buffer character devices are located.
+// By default, it is "/dev".
+func DevRoot(path string) Option {
+	return func(d *Device) Option {
+		old := d.devRoot
+		d.devRoot = path
+		return DevRoot(old)
+	}
+}
+
+// OnDevice selects the IIO device by its directory name (e.g.
+// "iio:device0"). By default, the first device whose name matches a
+// supported driver is used.
+func OnDevice(name string) Option {
+	return func(d *Device) Option {
+		old := d.id
+		d.id = name
+		return OnDevice(old)
+	}
+}
+
+// BatchSize sets the number of samples returned by IRRedBatch. By default,
+// it is 32, the size of the FIFO of the MAX30102.
+func BatchSize(n int) Option {
+	return func(d *Device) Option {
+		old := d.batch
+		d.batch = n
+		return BatchSize(old)
+	}
+}
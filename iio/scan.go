@@ -0,0 +1,113 @@
+package iio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// scanType describes how a channel is stored in the buffer, as reported by
+// the scan_elements/*_type files (e.g. "be:u18/32>>8").
+type scanType struct {
+	bigEndian   bool
+	signed      bool
+	realBits    uint
+	storageBits uint
+	shift       uint
+}
+
+func parseScanType(s string) (scanType, error) {
+	var t scanType
+
+	s = strings.TrimSpace(s)
+	parts := strings.SplitN(s, ":", 2)
+	if len(parts) != 2 || len(parts[1]) < 1 {
+		return t, fmt.Errorf("iio: invalid scan type %q", s)
+	}
+	switch parts[0] {
+	case "be":
+		t.bigEndian = true
+	case "le":
+	default:
+		return t, fmt.Errorf("iio: invalid endianness in scan type %q", s)
+	}
+
+	switch parts[1][0] {
+	case 's', 'S':
+		t.signed = true
+	case 'u', 'U':
+	default:
+		return t, fmt.Errorf("iio: invalid sign in scan type %q", s)
+	}
+
+	rest := parts[1][1:]
+	shift := "0"
+	if i := strings.Index(rest, ">>"); i >= 0 {
+		rest, shift = rest[:i], rest[i+2:]
+	}
+	bits := strings.SplitN(rest, "/", 2)
+	if len(bits) != 2 {
+		return t, fmt.Errorf("iio: invalid bits in scan type %q", s)
+	}
+	// Drop the repeat count, if any (e.g. "16X2").
+	if i := strings.Index(bits[1], "X"); i >= 0 {
+		bits[1] = bits[1][:i]
+	}
+
+	for _, f := range []struct {
+		s string
+		v *uint
+	}{
+		{bits[0], &t.realBits},
+		{bits[1], &t.storageBits},
+		{shift, &t.shift},
+	} {
+		v, err := strconv.ParseUint(f.s, 10, 8)
+		if err != nil {
+			return t, fmt.Errorf("iio: invalid scan type %q: %w", s, err)
+		}
+		*f.v = uint(v)
+	}
+
+	switch t.storageBits {
+	case 8, 16, 32, 64:
+	default:
+		return t, fmt.Errorf("iio: unsupported storage of %d bits in scan type %q", t.storageBits, s)
+	}
+
+	return t, nil
+}
+
+// bytes returns the size in bytes of the channel in the buffer.
+func (t scanType) bytes() int {
+	return int(t.storageBits / 8)
+}
+
+// decode returns the value of the channel stored in b.
+func (t scanType) decode(b []byte) int64 {
+	var order binary.ByteOrder = binary.LittleEndian
+	if t.bigEndian {
+		order = binary.BigEndian
+	}
+
+	var v uint64
+	switch t.storageBits {
+	case 8:
+		v = uint64(b[0])
+	case 16:
+		v = uint64(order.Uint16(b))
+	case 32:
+		v = uint64(order.Uint32(b))
+	case 64:
+		v = order.Uint64(b)
+	}
+
+	v >>= t.shift
+	v &= (1 << t.realBits) - 1
+	if t.signed && v&(1<<(t.realBits-1)) != 0 {
+		return int64(v) - (1 << t.realBits)
+	}
+
+	return int64(v)
+}
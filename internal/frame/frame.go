@@ -0,0 +1,125 @@
+// Package frame implements the framing shared by the bridge and remote
+// transports:
+//
+//	0xA5 | command (1) | length (2, big-endian) | payload (length) | CRC-8
+//
+// The CRC-8 (polynomial 0x07, initial value 0x00) covers the command, length
+// and payload bytes.
+package frame
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Frame constants
+const (
+	SOF = 0xA5
+
+	// MaxPayload is the maximum length of the payload of a frame.
+	MaxPayload = 0xFFFF
+)
+
+var (
+	// ErrCRC is returned when a frame is received with a wrong checksum.
+	ErrCRC = errors.New("frame: CRC mismatch")
+	// ErrTooLong is returned when sending a payload longer than MaxPayload.
+	ErrTooLong = errors.New("frame: frame too long")
+)
+
+// crc8 returns the CRC-8 (polynomial 0x07) of b, starting from crc.
+func crc8(crc byte, b []byte) byte {
+	for _, v := range b {
+		crc ^= v
+		for i := 0; i < 8; i++ {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// Write writes a frame with cmd and payload to w.
+func Write(w io.Writer, cmd byte, payload []byte) error {
+	if len(payload) > MaxPayload {
+		return ErrTooLong
+	}
+
+	b := make([]byte, 0, len(payload)+5)
+	b = append(b, SOF, cmd, 0, 0)
+	binary.BigEndian.PutUint16(b[2:], uint16(len(payload)))
+	b = append(b, payload...)
+	b = append(b, crc8(0, b[1:]))
+
+	if _, err := w.Write(b); err != nil {
+		return fmt.Errorf("frame: could not write frame: %w", err)
+	}
+
+	return nil
+}
+
+// NewReader returns a reader for the frames sent on r, with a buffer large
+// enough for the longest frame.
+func NewReader(r io.Reader) *bufio.Reader {
+	return bufio.NewReaderSize(r, MaxPayload+5)
+}
+
+// Read reads the next frame from r, which should be created with NewReader.
+// Bytes before the start of frame are skipped. A frame is only consumed once
+// it is complete and its CRC matches. Otherwise, only its start of frame is
+// dropped, so that the next call resyncs to the next one: a corrupted frame
+// returns ErrCRC, and a frame longer than the buffer of r is skipped.
+func Read(r *bufio.Reader) (cmd byte, payload []byte, err error) {
+	for {
+		if err := skip(r); err != nil {
+			return 0, nil, fmt.Errorf("frame: could not read frame: %w", err)
+		}
+
+		header, err := r.Peek(4)
+		if err != nil {
+			return 0, nil, fmt.Errorf("frame: could not read frame header: %w", err)
+		}
+		n := int(binary.BigEndian.Uint16(header[2:]))
+		if n+5 > r.Size() {
+			// Not a start of frame.
+			r.Discard(1)
+			continue
+		}
+
+		b, err := r.Peek(n + 5)
+		if err != nil {
+			return 0, nil, fmt.Errorf("frame: could not read frame payload: %w", err)
+		}
+		if crc8(0, b[1:n+4]) != b[n+4] {
+			r.Discard(1)
+			return 0, nil, ErrCRC
+		}
+
+		cmd = b[1]
+		payload = make([]byte, n)
+		copy(payload, b[4:])
+		r.Discard(n + 5)
+
+		return cmd, payload, nil
+	}
+}
+
+// skip skips the bytes of r before the next start of frame.
+func skip(r *bufio.Reader) error {
+	for {
+		b, err := r.Peek(1)
+		if err != nil {
+			return err
+		}
+		if b[0] == SOF {
+			return nil
+		}
+		r.Discard(1)
+	}
+}
@@ -0,0 +1,100 @@
+package frame
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func encode(t *testing.T, cmd byte, payload []byte) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	if err := Write(&b, cmd, payload); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestRead(t *testing.T) {
+	type result struct {
+		cmd     byte
+		payload string
+		err     error
+	}
+
+	frame := encode(t, 0x81, []byte("abc"))
+	corrupted := append([]byte{}, frame...)
+	corrupted[len(corrupted)-1] ^= 0xFF
+	concat := func(bs ...[]byte) []byte {
+		return bytes.Join(bs, nil)
+	}
+
+	tests := []struct {
+		name string
+		in   []byte
+		size int
+		want []result
+	}{
+		{
+			name: "frame",
+			in:   frame,
+			want: []result{{0x81, "abc", nil}},
+		},
+		{
+			name: "empty payload",
+			in:   encode(t, 0x82, nil),
+			want: []result{{0x82, "", nil}},
+		},
+		{
+			name: "garbage before",
+			in:   concat([]byte{0x00, 0x11, 0x22}, frame),
+			want: []result{{0x81, "abc", nil}},
+		},
+		{
+			name: "corrupted frame",
+			in:   concat(corrupted, frame),
+			want: []result{{err: ErrCRC}, {0x81, "abc", nil}},
+		},
+		{
+			name: "false start of frame",
+			// The length covers the start of the next frame.
+			in:   concat([]byte{SOF, 0x81, 0x00, 0x04}, frame),
+			want: []result{{err: ErrCRC}, {0x81, "abc", nil}},
+		},
+		{
+			name: "false start of frame longer than buffer",
+			in:   concat([]byte{SOF, 0x81, 0x01, 0x00}, frame),
+			size: 64,
+			want: []result{{0x81, "abc", nil}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReader(bytes.NewReader(tt.in))
+			if tt.size > 0 {
+				r = bufio.NewReaderSize(bytes.NewReader(tt.in), tt.size)
+			}
+
+			for i, want := range tt.want {
+				cmd, payload, err := Read(r)
+				if want.err != nil {
+					if !errors.Is(err, want.err) {
+						t.Fatalf("Read() #%d error = %v, want %v", i, err, want.err)
+					}
+					continue
+				}
+				if err != nil {
+					t.Fatalf("Read() #%d = %v", i, err)
+				}
+				if cmd != want.cmd || string(payload) != want.payload {
+					t.Errorf("Read() #%d = %#x %q, want %#x %q", i, cmd, payload, want.cmd, want.payload)
+				}
+			}
+			if _, _, err := Read(r); err == nil {
+				t.Error("Read() after the last frame = nil error, want EOF")
+			}
+		})
+	}
+}
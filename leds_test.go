@@ -0,0 +1,97 @@
+package max3010x
+
+import (
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+// mockBus is a register map with a FIFO that always holds a few samples of
+// sample. Reset and temperature conversions complete at once.
+type mockBus struct {
+	mu     sync.Mutex
+	regs   [256]byte
+	sample []byte
+}
+
+func newMockBus(sample []byte) *mockBus {
+	b := &mockBus{sample: sample}
+	b.regs[max30102.RegPartID] = max30102.PartID
+	b.regs[max30102.IntStat1] = max30102.AlmostFull | max30102.NewFIFOData
+	b.regs[max30102.FIFOWrPtr] = 4
+	return b
+}
+
+func (b *mockBus) Read(reg byte) (byte, error) {
+	r, err := b.ReadBytes(reg, 1)
+	if err != nil {
+		return 0, err
+	}
+	return r[0], nil
+}
+
+func (b *mockBus) ReadBytes(reg byte, n int) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	r := make([]byte, n)
+	for i := range r {
+		if reg == max30102.FIFOData {
+			r[i] = b.sample[i%len(b.sample)]
+			continue
+		}
+		r[i] = b.regs[reg]
+		reg++
+	}
+	return r, nil
+}
+
+func (b *mockBus) Write(reg, data byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch reg {
+	case max30102.ModeCfg:
+		data &^= max30102.ResetControl
+	case max30102.TempCfg:
+		data &^= max30102.TempEna
+	}
+	b.regs[reg] = data
+	return nil
+}
+
+func (b *mockBus) Close() {}
+
+func TestLEDsOrder(t *testing.T) {
+	tests := []struct {
+		name string
+		read func(d *Device) error
+	}{
+		{"batch", (*Device).leds},
+		{"single", (*Device).ledsSingle},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// In SpO2 mode, the red sample (1/4 of the full scale) comes
+			// before the IR one (1/2 of the full scale).
+			d, err := New(WithBus(newMockBus([]byte{0x01, 0x00, 0x00, 0x02, 0x00, 0x00})))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+
+			if err := tt.read(d); err != nil {
+				t.Fatal(err)
+			}
+			if got := d.redLED.last(); math.Abs(got-0.25) > 0.001 {
+				t.Errorf("red = %v, want 0.25", got)
+			}
+			if got := d.irLED.last(); math.Abs(got-0.5) > 0.001 {
+				t.Errorf("IR = %v, want 0.5", got)
+			}
+		})
+	}
+}
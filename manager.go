@@ -0,0 +1,218 @@
+package max3010x
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrDuplicateLabel is thrown when adding a device to a Manager with a
+	// label that is already used.
+	ErrDuplicateLabel = errors.New("max3010x: duplicate label")
+	// ErrUnknownLabel is thrown when accessing a device of a Manager with a
+	// label that does not exist.
+	ErrUnknownLabel = errors.New("max3010x: unknown label")
+)
+
+// Reading defines the values read from one device of a Manager. If Err is not
+// nil, the values read after the error are 0.
+type Reading struct {
+	HeartRate   float64
+	SpO2        float64
+	Temperature float64
+	Time        time.Time
+	Err         error
+}
+
+// Readings are the readings of all the devices of a Manager, keyed by label.
+type Readings map[string]Reading
+
+// Manager owns several labeled devices and reads them concurrently.
+type Manager struct {
+	mu      sync.Mutex
+	devices map[string]*Device
+}
+
+// NewManager returns a new empty manager.
+func NewManager() *Manager {
+	return &Manager{
+		devices: make(map[string]*Device),
+	}
+}
+
+// Add adds an already opened device to the manager with a label. The manager
+// closes the device when it is closed.
+func (m *Manager) Add(label string, d *Device) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.devices[label]; ok {
+		return fmt.Errorf("%w: %q", ErrDuplicateLabel, label)
+	}
+	m.devices[label] = d
+
+	return nil
+}
+
+// Open opens a new device with the options (see New) and adds it to the
+// manager with a label.
+func (m *Manager) Open(label string, options ...Option) (*Device, error) {
+	m.mu.Lock()
+	_, ok := m.devices[label]
+	m.mu.Unlock()
+	if ok {
+		return nil, fmt.Errorf("%w: %q", ErrDuplicateLabel, label)
+	}
+
+	d, err := New(options...)
+	if err != nil {
+		return nil, fmt.Errorf("max3010x: could not open %q: %w", label, err)
+	}
+	if err := m.Add(label, d); err != nil {
+		d.Close()
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// Remove removes the device with a label from the manager and returns it,
+// without closing it.
+func (m *Manager) Remove(label string) (*Device, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d, ok := m.devices[label]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownLabel, label)
+	}
+	delete(m.devices, label)
+
+	return d, nil
+}
+
+// Device returns the device with a label.
+func (m *Manager) Device(label string) (*Device, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	d, ok := m.devices[label]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownLabel, label)
+	}
+
+	return d, nil
+}
+
+// Labels returns the sorted labels of the devices.
+func (m *Manager) Labels() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	labels := make([]string, 0, len(m.devices))
+	for l := range m.devices {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+
+	return labels
+}
+
+// Read reads the temperature, heart rate and SpO2 of all the devices
+// concurrently. Each device is read as in Device.HeartRate and Device.SpO2,
+// so Read can take several seconds.
+func (m *Manager) Read() Readings {
+	m.mu.Lock()
+	devices := make(map[string]*Device, len(m.devices))
+	for l, d := range m.devices {
+		devices[l] = d
+	}
+	m.mu.Unlock()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	readings := make(Readings, len(devices))
+	for l, d := range devices {
+		wg.Add(1)
+		go func(l string, d *Device) {
+			defer wg.Done()
+			r := read(d)
+			mu.Lock()
+			readings[l] = r
+			mu.Unlock()
+		}(l, d)
+	}
+	wg.Wait()
+
+	return readings
+}
+
+// Run reads all the devices every interval until ctx is done, and sends the
+// combined readings on the returned channel. If a read takes longer than
+// interval, the next one starts right after it. The channel is closed when
+// ctx is done.
+func (m *Manager) Run(ctx context.Context, interval time.Duration) <-chan Readings {
+	ch := make(chan Readings)
+
+	go func() {
+		defer close(ch)
+		t := time.NewTicker(interval)
+		defer t.Stop()
+
+		for {
+			// The readings are evaluated before selecting, so do not start
+			// a read if ctx is already done.
+			if ctx.Err() != nil {
+				return
+			}
+			select {
+			case ch <- m.Read():
+			case <-ctx.Done():
+				return
+			}
+
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return ch
+}
+
+// Close closes all the devices of the manager.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for l, d := range m.devices {
+		d.Close()
+		delete(m.devices, l)
+	}
+}
+
+func read(d *Device) (r Reading) {
+	var err error
+	defer func() { r.Time = time.Now() }()
+
+	if r.Temperature, err = d.Temperature(); err != nil {
+		r.Err = err
+		return r
+	}
+	if r.HeartRate, err = d.HeartRate(); err != nil {
+		r.Err = err
+		return r
+	}
+	if r.SpO2, err = d.SpO2(); err != nil {
+		r.Err = err
+		return r
+	}
+
+	return r
+}
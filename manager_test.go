@@ -0,0 +1,258 @@
+package max3010x
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cgxeiji/max3010x/emulator"
+	"github.com/cgxeiji/max3010x/fault"
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+var errNotConcurrent = errors.New("devices were not read concurrently")
+
+// barrier is a max30102.Bus that, once armed, blocks the first temperature
+// measurement until all the buses sharing the barrier start one, or fails it
+// after a while.
+type barrier struct {
+	max30102.Bus
+
+	mu      sync.Mutex
+	armed   bool
+	arrived bool
+	shared  *arrivals
+}
+
+type arrivals struct {
+	mu  sync.Mutex
+	n   int
+	all chan struct{}
+}
+
+func newArrivals(n int) *arrivals {
+	return &arrivals{n: n, all: make(chan struct{})}
+}
+
+func (b *barrier) arm() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.armed = true
+}
+
+func (b *barrier) Write(reg, data byte) error {
+	b.mu.Lock()
+	wait := b.armed && !b.arrived && reg == max30102.TempCfg
+	if wait {
+		b.arrived = true
+	}
+	b.mu.Unlock()
+
+	if wait {
+		a := b.shared
+		a.mu.Lock()
+		a.n--
+		if a.n == 0 {
+			close(a.all)
+		}
+		a.mu.Unlock()
+
+		select {
+		case <-a.all:
+		case <-time.After(5 * time.Second):
+			return errNotConcurrent
+		}
+	}
+
+	return b.Bus.Write(reg, data)
+}
+
+// closer is a max30102.Bus that records when it is closed.
+type closer struct {
+	max30102.Bus
+
+	mu     sync.Mutex
+	closed bool
+}
+
+func (b *closer) Close() {
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	b.Bus.Close()
+}
+
+func (b *closer) isClosed() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.closed
+}
+
+func TestManagerRead(t *testing.T) {
+	if testing.Short() {
+		t.Skip("takes several seconds of emulated signal")
+	}
+
+	devices := []struct {
+		label string
+		bus   max30102.Bus
+		fault fault.Fault
+		// finger is true if the heart rate and SpO2 are measured.
+		finger bool
+		want   error
+	}{
+		{label: "finger", bus: emulator.New(emulator.Pulse(72, 97)), finger: true},
+		{label: "no finger", bus: emulator.New(emulator.Constant(0, 0)), want: ErrNotDetected},
+		{label: "broken", bus: emulator.New(emulator.Pulse(72, 97)), fault: fault.NACK(max30102.TempCfg), want: fault.ErrNACK},
+	}
+
+	m := NewManager()
+	defer m.Close()
+	shared := newArrivals(len(devices))
+	for _, dev := range devices {
+		bus := fault.Wrap(dev.bus)
+		b := &barrier{Bus: bus, shared: shared}
+		if _, err := m.Open(dev.label, WithBus(b)); err != nil {
+			t.Fatal(err)
+		}
+		// The faults and the barrier only apply to the readings.
+		if dev.fault != nil {
+			bus.Faults(dev.fault)
+		}
+		b.arm()
+	}
+
+	readings := m.Read()
+	if len(readings) != len(devices) {
+		t.Errorf("Read() returned %d readings, want %d", len(readings), len(devices))
+	}
+	for _, dev := range devices {
+		r, ok := readings[dev.label]
+		if !ok {
+			t.Errorf("no reading for %q", dev.label)
+			continue
+		}
+		if errors.Is(r.Err, errNotConcurrent) {
+			t.Fatalf("%q: %v", dev.label, r.Err)
+		}
+		if !errors.Is(r.Err, dev.want) {
+			t.Errorf("%q: Err = %v, want %v", dev.label, r.Err, dev.want)
+		}
+		if r.Time.IsZero() {
+			t.Errorf("%q: Time not set", dev.label)
+		}
+		// The temperature is read first, so it is only missing if it
+		// failed.
+		if got := r.Temperature != 0; got != (dev.label != "broken") {
+			t.Errorf("%q: Temperature = %v", dev.label, r.Temperature)
+		}
+		if got := r.HeartRate != 0 && r.SpO2 != 0; got != dev.finger {
+			t.Errorf("%q: HeartRate = %v, SpO2 = %v", dev.label, r.HeartRate, r.SpO2)
+		}
+	}
+}
+
+func TestManagerRun(t *testing.T) {
+	tests := []struct {
+		name string
+		// readings is the number of readings received before canceling.
+		readings int
+		interval time.Duration
+	}{
+		{name: "canceled before", interval: time.Millisecond},
+		{name: "canceled while waiting", readings: 1, interval: time.Hour},
+		{name: "canceled after reading", readings: 3, interval: time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			bus := fault.Wrap(emulator.New(emulator.Constant(0, 0)))
+			d, err := New(WithBus(bus))
+			if err != nil {
+				t.Fatal(err)
+			}
+			m := NewManager()
+			defer m.Close()
+			if err := m.Add("a", d); err != nil {
+				t.Fatal(err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.readings == 0 {
+				cancel()
+			}
+			count := bus.Count()
+			ch := m.Run(ctx, tt.interval)
+
+			for i := 0; i < tt.readings; i++ {
+				r := <-ch
+				if err := r["a"].Err; !errors.Is(err, ErrNotDetected) {
+					t.Errorf("reading %d: Err = %v, want ErrNotDetected", i, err)
+				}
+			}
+			cancel()
+
+			timeout := time.After(time.Second)
+			for {
+				select {
+				case _, ok := <-ch:
+					if ok {
+						continue
+					}
+				case <-timeout:
+					t.Fatal("Run() did not stop after canceling")
+				}
+				break
+			}
+			if tt.readings == 0 && bus.Count() != count {
+				t.Errorf("Run() read the device after being canceled")
+			}
+		})
+	}
+}
+
+func TestManagerClose(t *testing.T) {
+	m := NewManager()
+
+	buses := make(map[string]*closer)
+	for _, l := range []string{"b", "a", "c"} {
+		buses[l] = &closer{Bus: emulator.New(emulator.Constant(0, 0))}
+		if _, err := m.Open(l, WithBus(buses[l])); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := m.Open("a", WithBus(emulator.New(emulator.Constant(0, 0)))); !errors.Is(err, ErrDuplicateLabel) {
+		t.Errorf("Open() with a used label = %v, want ErrDuplicateLabel", err)
+	}
+	if got := m.Labels(); len(got) != 3 || got[0] != "a" || got[1] != "b" || got[2] != "c" {
+		t.Errorf("Labels() = %v, want [a b c]", got)
+	}
+
+	// Removed devices are not closed by the manager.
+	d, err := m.Remove("c")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	m.Close()
+	for l, b := range buses {
+		if got, want := b.isClosed(), l != "c"; got != want {
+			t.Errorf("bus %q closed = %v, want %v", l, got, want)
+		}
+	}
+	if got := m.Labels(); len(got) != 0 {
+		t.Errorf("Labels() after Close = %v, want none", got)
+	}
+	if _, err := m.Device("a"); !errors.Is(err, ErrUnknownLabel) {
+		t.Errorf("Device() after Close = %v, want ErrUnknownLabel", err)
+	}
+	if r := m.Read(); len(r) != 0 {
+		t.Errorf("Read() after Close = %v, want no readings", r)
+	}
+}
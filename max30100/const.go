@@ -0,0 +1,95 @@
+package max30100
+
+// Register addresses
+const (
+	IntStat   = 0x00
+	IntEna    = 0x01
+	FIFOWrPtr = 0x02
+	OvfCount  = 0x03
+	FIFORdPtr = 0x04
+	FIFOData  = 0x05
+	ModeCfg   = 0x06
+	SpO2Cfg   = 0x07
+	LedCfg    = 0x09
+	TempInt   = 0x16
+	TempFrac  = 0x17
+	RegRevID  = 0xFE
+	RegPartID = 0xFF
+)
+
+// Interrupt flags
+const (
+	AlmostFull byte = (1 << 7)
+	TempReady  byte = (1 << 6)
+	HRReady    byte = (1 << 5)
+	SpO2Ready  byte = (1 << 4)
+	PowerReady byte = (1 << 0)
+)
+
+// Device constants
+const (
+	Addr   = 0x57
+	PartID = 0x11
+)
+
+// Settings
+const (
+	ModeHR   byte = 0b010
+	ModeSpO2 byte = 0b011
+	modeMask byte = 0b1111_1000
+	TempEna  byte = (1 << 3)
+	modeSHDN byte = (1 << 7)
+
+	ResetControl = 0b0100_0000
+)
+
+// SpO2 Sample Rate Control
+const (
+	SR50 = (iota << 2)
+	SR100
+	SR167
+	SR200
+	SR400
+	SR600
+	SR800
+	SR1000
+
+	srMask byte = 0b1_1_1_000_11
+)
+
+// SpO2 ADC high resolution
+const (
+	SpO2HiRes byte = (1 << 6)
+	hiResMask byte = 0b1_0_1_111_11
+)
+
+// LED Pulse Width Control
+const (
+	PW200 = iota
+	PW400
+	PW800
+	PW1600
+
+	pwMask byte = 0b1_1_1_111_00
+)
+
+// LED current control. The MAX30100 only supports these discrete values for
+// the red and IR LEDs.
+var ledCurrents = [...]float64{
+	0.0, 4.4, 7.6, 11.0, 14.2, 17.4, 20.8, 24.0,
+	27.1, 30.6, 33.8, 37.0, 40.2, 43.6, 46.8, 50.0,
+}
+
+// masks
+const (
+	redMask byte = 0b0000_1111
+	irMask  byte = 0b1111_0000
+)
+
+const (
+	fifoDepth = 16
+	// batchSize is the number of samples in the FIFO when the AlmostFull
+	// flag is raised.
+	batchSize = 15
+	maxADC    = (1 << 16) - 1
+)
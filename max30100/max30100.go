@@ -0,0 +1,373 @@
+package max30100
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cgxeiji/serial"
+)
+
+var (
+	// ErrNotDevice throws an error when the device part ID does not match a
+	// MAX30100 signature (0x11).
+	ErrNotDevice error = errors.New("max30100: part ID does not match (0x11)")
+)
+
+// Device defines a MAX30100 device.
+type Device struct {
+	bus Bus
+
+	// sampleRate is the sample rate set, from SR50 to SR1000.
+	sampleRate byte
+}
+
+// New returns a new MAX30100 device. By default, this sets the IR LED pulse
+// amplitude to 50.0mA and the red LED pulse amplitude to 27.1mA, with a pulse
+// width of 1600us, 16-bit resolution and a sample rate of 100 samples/s.
+//
+// Argument "busName" can be used to specify the exact bus to use ("/dev/i2c-2", "I2C2", "2").
+// Argument "addr" can be used to specify alternative address if default (0x57) is unavailable and changed.
+// If "busName" argument is specified as an empty string "" the first available bus will be used.
+func New(busName string, addr uint16) (*Device, error) {
+	if addr == 0 {
+		addr = Addr
+	}
+
+	i2c, err := serial.NewI2C(busName, addr)
+	if err != nil {
+		return nil, fmt.Errorf("max30100: could not initialize I2C: %w", err)
+	}
+
+	d, err := NewWithBus(i2c)
+	if err != nil {
+		i2c.Close()
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// NewWithBus returns a new MAX30100 device that communicates through bus. The
+// device is initialized with the same defaults as New. The bus is owned by
+// the device and closed when the device is closed.
+func NewWithBus(bus Bus) (*Device, error) {
+	d := &Device{
+		bus: bus,
+	}
+
+	part, err := d.Read(RegPartID)
+	if err != nil {
+		return nil, fmt.Errorf("max30100: could not get part ID: %w", err)
+	}
+	if part != PartID {
+		return nil, ErrNotDevice
+	}
+
+	err = d.Reset()
+	if err != nil {
+		return nil, fmt.Errorf("max30100: could not reset device: %w", err)
+	}
+	if _, err = d.Options(
+		RedPulseAmp(27.1),
+		IRPulseAmp(50),
+		PulseWidth(PW1600),
+		SampleRate(SR100),
+		HighResolution(true),
+		InterruptEnable(SpO2Ready|AlmostFull),
+		Mode(ModeSpO2),
+	); err != nil {
+		return nil, fmt.Errorf("max30100: could not initialize device: %w", err)
+	}
+	if err := d.drain(); err != nil {
+		return nil, fmt.Errorf("max30100: could not empty FIFO: %w", err)
+	}
+
+	return d, nil
+}
+
+// Close closes the device and cleans after itself.
+func (d *Device) Close() {
+	d.Shutdown()
+	d.bus.Close()
+}
+
+// RevID returns the revision ID of the device.
+func (d *Device) RevID() (byte, error) {
+	rev, err := d.Read(RegRevID)
+	if err != nil {
+		return 0, fmt.Errorf("max30100: could not get revision ID: %w", err)
+	}
+	return rev, nil
+}
+
+// Temperature returns the current temperature of the device. It times out
+// after 100ms.
+func (d *Device) Temperature() (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tempTimeout)
+	defer cancel()
+
+	return d.TemperatureContext(ctx)
+}
+
+// TemperatureContext returns the current temperature of the device, or an
+// error if ctx is done before the temperature is measured.
+func (d *Device) TemperatureContext(ctx context.Context) (float64, error) {
+	if _, err := d.config(ModeCfg, ^TempEna, TempEna); err != nil {
+		return 0, fmt.Errorf("max30100: could not enable temperature: %w", err)
+	}
+	if err := d.waitUntil(ctx, ModeCfg, TempEna, 0); err != nil {
+		return 0, fmt.Errorf("max30100: could not measure temperature: %w", err)
+	}
+
+	i, err := d.Read(TempInt)
+	if err != nil {
+		return 0, fmt.Errorf("max30100: could not read integer part of temperature: %w", err)
+	}
+
+	f, err := d.Read(TempFrac)
+	if err != nil {
+		return 0, fmt.Errorf("max30100: could not read fractional part of temperature: %w", err)
+	}
+
+	return float64(int8(i)) + (float64(f&0x0F) * 0.0625), nil
+}
+
+// Read reads a single byte from a register.
+func (d *Device) Read(reg byte) (byte, error) {
+	return d.bus.Read(reg)
+}
+
+// ReadBytes reads n bytes from a register.
+func (d *Device) ReadBytes(reg byte, n int) ([]byte, error) {
+	return d.bus.ReadBytes(reg, n)
+}
+
+// Write writes a byte to a register.
+func (d *Device) Write(reg, data byte) error {
+	return d.bus.Write(reg, data)
+}
+
+// Reset resets the device. All configurations, thresholds, and data registers
+// are reset to their power-on state. It times out after 100ms.
+func (d *Device) Reset() error {
+	ctx, cancel := context.WithTimeout(context.Background(), resetTimeout)
+	defer cancel()
+
+	return d.ResetContext(ctx)
+}
+
+// ResetContext resets the device, or returns an error if ctx is done before
+// the reset completes.
+func (d *Device) ResetContext(ctx context.Context) error {
+	if err := d.Write(ModeCfg, ResetControl); err != nil {
+		return fmt.Errorf("max30100: could not reset: %w", err)
+	}
+	if err := d.waitUntil(ctx, ModeCfg, ResetControl, 0); err != nil {
+		return fmt.Errorf("max30100: could not reset: %w", err)
+	}
+	d.sampleRate = SR50
+
+	return nil
+}
+
+// decode returns the IR and red values of a 4-byte FIFO sample. The values
+// are normalized from 0.0 to 1.0.
+func decode(bytes []byte) (ir, red float64) {
+	ir = float64(int(bytes[0])<<8|int(bytes[1])) / maxADC
+	red = float64(int(bytes[2])<<8|int(bytes[3])) / maxADC
+	return ir, red
+}
+
+// IRRed returns the value of the red LED and IR LED. The values are normalized
+// from 0.0 to 1.0. In heart rate mode, the red value is always 0. It times out
+// if no sample is taken within the time expected from the sample rate.
+func (d *Device) IRRed() (ir, red float64, err error) {
+	ctx, cancel := d.sampleTimeout(1)
+	defer cancel()
+
+	return d.IRRedContext(ctx)
+}
+
+// IRRedContext returns the value of the red LED and IR LED (see IRRed), or an
+// error if ctx is done before a sample is taken.
+func (d *Device) IRRedContext(ctx context.Context) (ir, red float64, err error) {
+	err = d.waitUntil(ctx, IntStat, SpO2Ready|HRReady, 1)
+	if err != nil {
+		return 0, 0, fmt.Errorf("max30100: error waiting for new sample: %w", err)
+	}
+
+	bytes, err := d.ReadBytes(FIFOData, 4)
+	if err != nil {
+		return 0, 0, fmt.Errorf("max30100: could not read FIFO: %w", err)
+	}
+
+	ir, red = decode(bytes)
+
+	return ir, red, nil
+}
+
+// IRRedBatch returns a batch of IR and red LED values based on the AlmostFull
+// flag, which is triggered when the FIFO has 15 samples. It times out if the
+// batch is not taken within the time expected from the sample rate.
+func (d *Device) IRRedBatch() (ir, red []float64, err error) {
+	ctx, cancel := d.sampleTimeout(batchSize)
+	defer cancel()
+
+	return d.IRRedBatchContext(ctx)
+}
+
+// IRRedBatchContext returns a batch of IR and red LED values (see
+// IRRedBatch), or an error if ctx is done before the batch is taken.
+func (d *Device) IRRedBatchContext(ctx context.Context) (ir, red []float64, err error) {
+	err = d.drain()
+	if err != nil {
+		return nil, nil, fmt.Errorf("max30100: could not empty FIFO: %w", err)
+	}
+	// Reading the FIFO does not clear the AlmostFull flag.
+	if _, err := d.Read(IntStat); err != nil {
+		return nil, nil, fmt.Errorf("max30100: could not read interrupt status: %w", err)
+	}
+	err = d.waitUntil(ctx, IntStat, AlmostFull, 1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("max30100: error waiting for almost full interrupt: %w", err)
+	}
+
+	n, err := d.available(true)
+	if err != nil {
+		return nil, nil, fmt.Errorf("max30100: error reading available data: %w", err)
+	}
+
+	ir = make([]float64, n)
+	red = make([]float64, n)
+	if n == 0 {
+		return ir, red, nil
+	}
+	bytes, err := d.ReadBytes(FIFOData, 4*n)
+	if err != nil {
+		return nil, nil, fmt.Errorf("max30100: could not read FIFO: %w", err)
+	}
+	for i := 0; i < n; i++ {
+		ir[i], red[i] = decode(bytes[4*i:])
+	}
+
+	return ir, red, nil
+}
+
+func (d *Device) drain() error {
+	n, err := d.available(false)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return nil
+	}
+	_, err = d.ReadBytes(FIFOData, 4*n)
+	return err
+}
+
+// available returns the number of samples in the FIFO. When the pointers are
+// equal, the FIFO is either empty or full: it is full if samples were lost, or
+// if almostFull is set (the AlmostFull flag was just seen while waiting). A
+// raised AlmostFull flag is not enough, as it is not cleared by reading the
+// FIFO and can be left over from samples already read.
+func (d *Device) available(almostFull bool) (int, error) {
+	p, err := d.ReadBytes(FIFOWrPtr, 3)
+	if err != nil {
+		return 0, err
+	}
+	wr, ovf, rd := p[0], p[1], p[2]
+
+	if wr == rd {
+		if ovf != 0 || almostFull {
+			return fifoDepth, nil
+		}
+		return 0, nil
+	}
+	return (int(wr) + fifoDepth - int(rd)) % fifoDepth, nil
+}
+
+// Calibrate auto-calibrates the current of each LED.
+func (d *Device) Calibrate() error {
+	var ir []float64
+	var red []float64
+	var err error
+
+	irCode := 0
+	redCode := 0
+
+	if _, err = d.Options(
+		IRPulseAmp(ledCurrents[irCode]),
+		RedPulseAmp(ledCurrents[redCode]),
+	); err != nil {
+		return fmt.Errorf("max30100: could not calibrate sensor: %w", err)
+	}
+
+	for mean(ir) < 0.4 {
+		if irCode >= len(ledCurrents)-1 {
+			break
+		}
+		irCode++
+
+		if _, err = d.Options(
+			IRPulseAmp(ledCurrents[irCode]),
+		); err != nil {
+			return fmt.Errorf("max30100: could not calibrate sensor: %w", err)
+		}
+		time.Sleep(40 * time.Millisecond)
+
+		ir, red, err = d.IRRedBatch()
+		if err != nil {
+			return fmt.Errorf("max30100: could not calibrate sensor: %w", err)
+		}
+	}
+
+	for mean(red) < 0.4 {
+		if redCode >= len(ledCurrents)-1 {
+			break
+		}
+		redCode++
+
+		if _, err = d.Options(
+			RedPulseAmp(ledCurrents[redCode]),
+		); err != nil {
+			return fmt.Errorf("max30100: could not calibrate sensor: %w", err)
+		}
+		time.Sleep(40 * time.Millisecond)
+
+		ir, red, err = d.IRRedBatch()
+		if err != nil {
+			return fmt.Errorf("max30100: could not calibrate sensor: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func mean(a []float64) float64 {
+	if len(a) == 0 {
+		return 0
+	}
+
+	r := 0.0
+	for _, v := range a {
+		r += v
+	}
+
+	return r / float64(len(a))
+}
+
+// Shutdown sets the device into power-save mode.
+func (d *Device) Shutdown() error {
+	_, err := d.config(ModeCfg, ^modeSHDN, modeSHDN)
+
+	return err
+}
+
+// Startup wakes the device from power-save mode.
+func (d *Device) Startup() error {
+	_, err := d.config(ModeCfg, ^modeSHDN, ^modeSHDN)
+
+	return err
+}
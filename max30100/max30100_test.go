@@ -0,0 +1,308 @@
+package max30100_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/cgxeiji/max3010x/max30100"
+)
+
+// mockBus is a register map. Writing the reset bit clears the registers and
+// temperature conversions complete at once, unless their bits are stuck.
+// Reading the FIFO returns sample.
+type mockBus struct {
+	regs     [256]byte
+	stuck    byte
+	sample   [4]byte
+	readErr  map[byte]error
+	writeErr map[byte]error
+}
+
+func newMockBus() *mockBus {
+	b := &mockBus{}
+	b.regs[max30100.RegPartID] = max30100.PartID
+	return b
+}
+
+func (b *mockBus) Read(reg byte) (byte, error) {
+	if err := b.readErr[reg]; err != nil {
+		return 0, err
+	}
+	if reg == max30100.ModeCfg {
+		return b.regs[reg] | b.stuck, nil
+	}
+	return b.regs[reg], nil
+}
+
+func (b *mockBus) ReadBytes(reg byte, n int) ([]byte, error) {
+	if reg == max30100.FIFOData {
+		if err := b.readErr[reg]; err != nil {
+			return nil, err
+		}
+		r := make([]byte, n)
+		for i := range r {
+			r[i] = b.sample[i%len(b.sample)]
+		}
+		return r, nil
+	}
+
+	r := make([]byte, n)
+	for i := range r {
+		v, err := b.Read(reg)
+		if err != nil {
+			return nil, err
+		}
+		r[i] = v
+		reg++
+	}
+	return r, nil
+}
+
+func (b *mockBus) Write(reg, data byte) error {
+	if err := b.writeErr[reg]; err != nil {
+		return err
+	}
+	if reg == max30100.ModeCfg {
+		if data&max30100.ResetControl != 0 {
+			part := b.regs[max30100.RegPartID]
+			b.regs = [256]byte{}
+			b.regs[max30100.RegPartID] = part
+			return nil
+		}
+		data &^= max30100.TempEna
+	}
+	b.regs[reg] = data
+	return nil
+}
+
+func (b *mockBus) Close() {}
+
+func TestNewWithBus(t *testing.T) {
+	errBus := errors.New("bus error")
+
+	tests := []struct {
+		name    string
+		setup   func(b *mockBus)
+		wantErr error
+	}{
+		{name: "defaults"},
+		{
+			name:    "wrong part",
+			setup:   func(b *mockBus) { b.regs[max30100.RegPartID] = 0x15 },
+			wantErr: max30100.ErrNotDevice,
+		},
+		{
+			name:    "part ID read error",
+			setup:   func(b *mockBus) { b.readErr = map[byte]error{max30100.RegPartID: errBus} },
+			wantErr: errBus,
+		},
+		{
+			name:    "configuration write error",
+			setup:   func(b *mockBus) { b.writeErr = map[byte]error{max30100.SpO2Cfg: errBus} },
+			wantErr: errBus,
+		},
+		{
+			name:    "reset stuck",
+			setup:   func(b *mockBus) { b.stuck = max30100.ResetControl },
+			wantErr: max30100.ErrTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := newMockBus()
+			if tt.setup != nil {
+				tt.setup(bus)
+			}
+
+			d, err := max30100.NewWithBus(bus)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("NewWithBus() = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewWithBus() = %v", err)
+			}
+			defer d.Close()
+
+			for reg, want := range map[byte]byte{
+				max30100.ModeCfg: max30100.ModeSpO2,
+				max30100.SpO2Cfg: max30100.SpO2HiRes | max30100.SR100 | max30100.PW1600,
+				max30100.LedCfg:  0x8F, // 27.1mA red, 50mA IR
+				max30100.IntEna:  max30100.SpO2Ready | max30100.AlmostFull,
+			} {
+				if got := bus.regs[reg]; got != want {
+					t.Errorf("register %#x = %#x, want %#x", reg, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  max30100.Option
+		reg  byte
+		want byte
+	}{
+		{"SampleRate", max30100.SampleRate(max30100.SR400), max30100.SpO2Cfg, max30100.SpO2HiRes | max30100.SR400 | max30100.PW1600},
+		{"PulseWidth", max30100.PulseWidth(max30100.PW200), max30100.SpO2Cfg, max30100.SpO2HiRes | max30100.SR100 | max30100.PW200},
+		{"HighResolution", max30100.HighResolution(false), max30100.SpO2Cfg, max30100.SR100 | max30100.PW1600},
+		{"Mode", max30100.Mode(max30100.ModeHR), max30100.ModeCfg, max30100.ModeHR},
+		{"RedPulseAmp", max30100.RedPulseAmp(11), max30100.LedCfg, 0x3F},
+		{"IRPulseAmp", max30100.IRPulseAmp(8), max30100.LedCfg, 0x82},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := newMockBus()
+			d, err := max30100.NewWithBus(bus)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+			old := bus.regs[tt.reg]
+
+			undo, err := d.Options(tt.opt)
+			if err != nil {
+				t.Fatalf("Options() = %v", err)
+			}
+			if got := bus.regs[tt.reg]; got != tt.want {
+				t.Errorf("register %#x = %#x, want %#x", tt.reg, got, tt.want)
+			}
+
+			if _, err := d.Options(undo); err != nil {
+				t.Fatalf("Options(undo) = %v", err)
+			}
+			if got := bus.regs[tt.reg]; got != old {
+				t.Errorf("register %#x after undo = %#x, want %#x", tt.reg, got, old)
+			}
+		})
+	}
+}
+
+func TestTemperature(t *testing.T) {
+	errBus := errors.New("bus error")
+
+	tests := []struct {
+		name    string
+		setup   func(b *mockBus)
+		want    float64
+		wantErr error
+	}{
+		{name: "positive", want: 25.25},
+		{
+			name: "negative",
+			setup: func(b *mockBus) {
+				b.regs[max30100.TempInt] = 0xF6 // -10
+				b.regs[max30100.TempFrac] = 0x08
+			},
+			want: -9.5,
+		},
+		{
+			name:    "conversion stuck",
+			setup:   func(b *mockBus) { b.stuck = max30100.TempEna },
+			wantErr: max30100.ErrTimeout,
+		},
+		{
+			name:    "read error",
+			setup:   func(b *mockBus) { b.readErr = map[byte]error{max30100.TempInt: errBus} },
+			wantErr: errBus,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := newMockBus()
+			d, err := max30100.NewWithBus(bus)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+			bus.regs[max30100.TempInt] = 25
+			bus.regs[max30100.TempFrac] = 0x04
+			if tt.setup != nil {
+				tt.setup(bus)
+			}
+
+			got, err := d.Temperature()
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Temperature() = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Temperature() = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Temperature() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeout(t *testing.T) {
+	bus := newMockBus()
+	d, err := max30100.NewWithBus(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	// No flag is ever raised.
+	if _, _, err := d.IRRed(); !errors.Is(err, max30100.ErrTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("IRRed() = %v, want ErrTimeout and DeadlineExceeded", err)
+	}
+	if _, _, err := d.IRRedBatch(); !errors.Is(err, max30100.ErrTimeout) {
+		t.Errorf("IRRedBatch() = %v, want ErrTimeout", err)
+	}
+}
+
+func TestIRRedBatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		wr, ovf, rd byte
+		want        int
+	}{
+		{"almost full", 15, 0, 0, 15},
+		{"full", 3, 0, 3, 16},
+		{"overflowed", 3, 4, 3, 16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := newMockBus()
+			d, err := max30100.NewWithBus(bus)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+
+			// IR at 1/4 and red at 1/2 of the full scale.
+			bus.sample = [4]byte{0x3F, 0xFF, 0x7F, 0xFF}
+			bus.regs[max30100.IntStat] = max30100.AlmostFull
+			bus.regs[max30100.FIFOWrPtr] = tt.wr
+			bus.regs[max30100.OvfCount] = tt.ovf
+			bus.regs[max30100.FIFORdPtr] = tt.rd
+
+			ir, red, err := d.IRRedBatch()
+			if err != nil {
+				t.Fatalf("IRRedBatch() = %v", err)
+			}
+			if len(ir) != tt.want || len(red) != tt.want {
+				t.Fatalf("IRRedBatch() returned %d IR and %d red values, want %d", len(ir), len(red), tt.want)
+			}
+			for i := range ir {
+				if math.Abs(ir[i]-0.25) > 0.001 || math.Abs(red[i]-0.5) > 0.001 {
+					t.Fatalf("IRRedBatch()[%d] = %v, %v, want 0.25, 0.5", i, ir[i], red[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package max30100
+
+import "fmt"
+
+// Option defines a functional option for the device.
+type Option func(d *Device) (Option, error)
+
+// Options sets different configuration options and returns the previous value
+// of the last option passed.
+func (d *Device) Options(options ...Option) (Option, error) {
+	var old Option
+	var err error
+	for _, opt := range options {
+		old, err = opt(d)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return old, nil
+}
+
+func (d *Device) config(reg, mask, flag byte) (byte, error) {
+	cfg, err := d.Read(reg)
+	if err != nil {
+		return 0, fmt.Errorf("could not get %v from %v: %w", mask, reg, err)
+	}
+	old := cfg &^ mask
+	cfg &= mask
+	flag = flag &^ mask
+	cfg |= flag
+	if err := d.Write(reg, cfg); err != nil {
+		return 0, fmt.Errorf("could not set %v in %v: %w", flag, reg, err)
+	}
+
+	return old, nil
+}
+
+// Mode sets the operation mode of the device.
+func Mode(mode byte) Option {
+	return func(d *Device) (Option, error) {
+		old, err := d.config(ModeCfg, modeMask, mode)
+		if err != nil {
+			return nil, fmt.Errorf("max30100: could not configure mode %#x: %w", mode, err)
+		}
+
+		if err = d.Write(FIFOWrPtr, 0); err != nil {
+			return nil, fmt.Errorf("max30100: could not configure mode %#x: %w", mode, err)
+		}
+		if err = d.Write(OvfCount, 0); err != nil {
+			return nil, fmt.Errorf("max30100: could not configure mode %#x: %w", mode, err)
+		}
+		if err = d.Write(FIFORdPtr, 0); err != nil {
+			return nil, fmt.Errorf("max30100: could not configure mode %#x: %w", mode, err)
+		}
+
+		return Mode(old), nil
+	}
+}
+
+// ledCode returns the register value of the highest LED current that does
+// not exceed current.
+func ledCode(current float64) byte {
+	var code byte
+	for i, c := range ledCurrents {
+		if c <= current {
+			code = byte(i)
+		}
+	}
+	return code
+}
+
+// RedPulseAmp sets the pulse amplitude of the red LED. It accepts values
+// from 0.0 to 50.0 mA and the value is rounded down to the nearest current
+// supported by the device (0.0, 4.4, 7.6, 11.0, 14.2, 17.4, 20.8, 24.0, 27.1,
+// 30.6, 33.8, 37.0, 40.2, 43.6, 46.8 or 50.0 mA).
+func RedPulseAmp(current float64) Option {
+	return func(d *Device) (Option, error) {
+		old, err := d.config(LedCfg, redMask, ledCode(current)<<4)
+		if err != nil {
+			return nil, fmt.Errorf("max30100: could not configure red LED pulse amplitud: %w", err)
+		}
+
+		return RedPulseAmp(ledCurrents[old>>4]), nil
+	}
+}
+
+// IRPulseAmp sets the pulse amplitude of the IR LED. It accepts values
+// from 0.0 to 50.0 mA and the value is rounded down to the nearest current
+// supported by the device (see RedPulseAmp).
+func IRPulseAmp(current float64) Option {
+	return func(d *Device) (Option, error) {
+		old, err := d.config(LedCfg, irMask, ledCode(current))
+		if err != nil {
+			return nil, fmt.Errorf("max30100: could not configure IR LED pulse amplitud: %w", err)
+		}
+
+		return IRPulseAmp(ledCurrents[old]), nil
+	}
+}
+
+// PulseWidth sets the pulse width of the device.
+func PulseWidth(pw byte) Option {
+	return func(d *Device) (Option, error) {
+		old, err := d.config(SpO2Cfg, pwMask, pw)
+		if err != nil {
+			return nil, fmt.Errorf("max30100: could not configure pulse width: %w", err)
+		}
+
+		return PulseWidth(old), nil
+	}
+}
+
+// SampleRate sets the SpO2 sample rate control of the device.
+func SampleRate(sr byte) Option {
+	return func(d *Device) (Option, error) {
+		old, err := d.config(SpO2Cfg, srMask, sr)
+		if err != nil {
+			return nil, fmt.Errorf("max30100: could not configure sample rate: %w", err)
+		}
+		d.sampleRate = sr &^ srMask
+
+		return SampleRate(old), nil
+	}
+}
+
+// HighResolution enables or disables the 16-bit SpO2 ADC resolution.
+func HighResolution(enable bool) Option {
+	return func(d *Device) (Option, error) {
+		flag := byte(0)
+		if enable {
+			flag = SpO2HiRes
+		}
+		old, err := d.config(SpO2Cfg, hiResMask, flag)
+		if err != nil {
+			return nil, fmt.Errorf("max30100: could not configure high resolution: %w", err)
+		}
+
+		return HighResolution(old != 0), nil
+	}
+}
+
+// InterruptEnable enables interrupts.
+func InterruptEnable(i byte) Option {
+	return func(d *Device) (Option, error) {
+		old, err := d.config(IntEna, ^i, i)
+		if err != nil {
+			return nil, fmt.Errorf("max30100: could not configure interrupt flags: %w", err)
+		}
+
+		return InterruptEnable(old), nil
+	}
+}
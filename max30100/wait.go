@@ -0,0 +1,118 @@
+package max30100
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrTimeout throws an error when a flag of the device is not set before
+	// the deadline. It also matches context.DeadlineExceeded.
+	ErrTimeout error = errors.New("max30100: timed out")
+
+	errInvalidBit = errors.New("invalid bit, it should be 1 or 0")
+)
+
+// Default deadlines of the functions without a context.
+const (
+	// resetTimeout and tempTimeout are well above the reset time and the
+	// temperature conversion time (29ms).
+	resetTimeout = 100 * time.Millisecond
+	tempTimeout  = 100 * time.Millisecond
+	// sampleMargin is added to the time needed to take the samples waited
+	// for, to allow for slow buses.
+	sampleMargin = 100 * time.Millisecond
+	// maxPoll is the longest time between two reads of a flag while polling.
+	maxPoll = 5 * time.Millisecond
+)
+
+// sampleRates are the sample rates in samples/s, indexed by SR50 to SR1000.
+var sampleRates = [...]int{50, 100, 167, 200, 400, 600, 800, 1000}
+
+// waitUntil waits until flag in reg is set to bit, or until ctx is done.
+func (d *Device) waitUntil(ctx context.Context, reg, flag byte, bit byte) error {
+	if bit > 1 {
+		return errInvalidBit
+	}
+
+	var poll *time.Timer
+	for {
+		state, err := d.Read(reg)
+		if err != nil {
+			return fmt.Errorf("could not wait for %v in %v to be %v: %w", flag, reg, bit, err)
+		}
+		if (state&flag != 0) == (bit == 1) {
+			return nil
+		}
+
+		if poll == nil {
+			poll = time.NewTimer(d.pollInterval())
+			defer poll.Stop()
+		} else {
+			poll.Reset(d.pollInterval())
+		}
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("could not wait for %v in %v to be %v: %w", flag, reg, bit, ctxErr(ctx))
+		case <-poll.C:
+		}
+	}
+}
+
+// pollInterval returns the time between two reads of a flag while polling: a
+// fraction of the sample period, so that samples are read soon after they are
+// taken without flooding the bus.
+func (d *Device) pollInterval() time.Duration {
+	p := d.SamplePeriod() / 4
+	if p > maxPoll {
+		p = maxPoll
+	}
+	return p
+}
+
+// ctxErr returns an error matching both ErrTimeout and
+// context.DeadlineExceeded if the deadline of ctx was exceeded, or the error
+// of ctx otherwise.
+func ctxErr(ctx context.Context) error {
+	err := ctx.Err()
+	if errors.Is(err, context.DeadlineExceeded) {
+		return timeoutError{err}
+	}
+	return err
+}
+
+// timeoutError wraps the error of a context whose deadline was exceeded, so
+// that it matches ErrTimeout as well.
+type timeoutError struct {
+	err error
+}
+
+func (e timeoutError) Error() string {
+	return ErrTimeout.Error()
+}
+
+func (e timeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+func (e timeoutError) Unwrap() error {
+	return e.err
+}
+
+// SamplePeriod returns the nominal time between two FIFO samples, based on
+// the sample rate configured.
+func (d *Device) SamplePeriod() time.Duration {
+	return time.Second / time.Duration(sampleRates[d.sampleRate>>2])
+}
+
+// sampleTimeout returns a context with the default deadline to wait for n
+// FIFO samples.
+func (d *Device) sampleTimeout(n int) (context.Context, context.CancelFunc) {
+	// Wait for one more sample, as the current one could have just been
+	// missed.
+	timeout := time.Duration(n+1)*d.SamplePeriod() + sampleMargin
+
+	return context.WithTimeout(context.Background(), timeout)
+}
@@ -0,0 +1,97 @@
+// Package max30101 implements the MAX30101, a MAX30102 with an additional
+// green LED. The register map is shared with the MAX30102, so the device
+// reuses the max30102 driver and its options, and adds helpers to read the
+// green LED in multi-LED mode.
+package max30101
+
+import (
+	"fmt"
+
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+// Device constants. The MAX30101 reports the same part ID as the MAX30102.
+const (
+	Addr   = max30102.Addr
+	PartID = max30102.PartID
+)
+
+// Device defines a MAX30101 device. All the low level functions of the
+// max30102 driver are available.
+type Device struct {
+	*max30102.Device
+}
+
+// NewWithBus returns a new MAX30101 device that communicates through bus,
+// with the same defaults as New.
+func NewWithBus(bus max30102.Bus) (*Device, error) {
+	dev, err := max30102.NewWithBus(bus)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := setup(dev)
+	if err != nil {
+		dev.Close()
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func setup(dev *max30102.Device) (*Device, error) {
+	if _, err := dev.Options(
+		max30102.GreenPulseAmp(2.8),
+		max30102.LEDSlots(max30102.SlotRed, max30102.SlotIR, max30102.SlotGreen),
+		max30102.Mode(max30102.ModeMultiLed),
+	); err != nil {
+		return nil, fmt.Errorf("max30101: could not initialize device: %w", err)
+	}
+
+	return &Device{
+		Device: dev,
+	}, nil
+}
+
+// channel returns the index of the green LED in the values returned by the
+// LEDs functions.
+func (d *Device) channel() (int, error) {
+	for i, s := range d.Slots() {
+		if s == max30102.SlotGreen {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("max30101: green LED is not being sampled: %w", max30102.ErrNoSlots)
+}
+
+// Green returns the value of the green LED. The value is normalized from 0.0
+// to 1.0.
+func (d *Device) Green() (float64, error) {
+	ch, err := d.channel()
+	if err != nil {
+		return 0, err
+	}
+
+	leds, err := d.LEDs()
+	if err != nil {
+		return 0, err
+	}
+
+	return leds[ch], nil
+}
+
+// GreenBatch returns a batch of green LED values based on the AlmostFull flag
+// (see max30102.Device.IRRedBatch).
+func (d *Device) GreenBatch() ([]float64, error) {
+	ch, err := d.channel()
+	if err != nil {
+		return nil, err
+	}
+
+	leds, err := d.LEDsBatch()
+	if err != nil {
+		return nil, err
+	}
+
+	return leds[ch], nil
+}
@@ -0,0 +1,15 @@
+package max30102
+
+// Bus defines the register-level transport used to communicate with the
+// device. A *serial.I2C from github.com/cgxeiji/serial satisfies this
+// interface and is used by default.
+type Bus interface {
+	// Read reads a single byte from a register.
+	Read(reg byte) (byte, error)
+	// ReadBytes reads n bytes starting from a register.
+	ReadBytes(reg byte, n int) ([]byte, error)
+	// Write writes a byte to a register.
+	Write(reg, data byte) error
+	// Close releases the resources used by the bus.
+	Close()
+}
@@ -0,0 +1,231 @@
+package max30102
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Config defines all the configurable settings of the device.
+type Config struct {
+	Mode ModeConfig
+	SpO2 SpO2Config
+	FIFO FIFOConfig
+	Int1 IntEnable1
+	Int2 IntEnable2
+
+	// Pulse amplitudes of the LEDs in mA, rounded down to the nearest
+	// multiple of 0.2 when applied.
+	RedPulseAmp   float64
+	IRPulseAmp    float64
+	GreenPulseAmp float64
+
+	// Slots are the LEDs driving each time slot in multi-LED mode (see
+	// LEDSlots).
+	Slots [maxSlots]byte
+}
+
+// Change defines a configuration register that differs between two
+// configurations.
+type Change struct {
+	Reg byte
+	Old byte
+	New byte
+}
+
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", regName(c.Reg), describe(c.Reg, c.Old), describe(c.Reg, c.New))
+}
+
+// configRegs are the configuration registers, in the order they are written.
+var configRegs = [...]byte{
+	IntEna1, IntEna2, FIFOCfg, ModeCfg, SpO2Cfg,
+	Led1PA, Led2PA, Led3PA,
+	MultiLedModeS2S1, MultiLedModeS4S3,
+}
+
+// Config returns the current configuration of the device, read from its
+// registers.
+func (d *Device) Config() (Config, error) {
+	var regs [len(configRegs)]byte
+	for i, reg := range configRegs {
+		b, err := d.Read(reg)
+		if err != nil {
+			return Config{}, fmt.Errorf("max30102: could not read configuration: %w", err)
+		}
+		regs[i] = b
+	}
+
+	return decodeConfig(regs), nil
+}
+
+// Apply writes the registers of c that differ from the current
+// configuration of the device. The FIFO is emptied if the mode or the LED
+// slots change.
+func (d *Device) Apply(c Config) error {
+	cur, err := d.Config()
+	if err != nil {
+		return err
+	}
+
+	changes := Diff(cur, c)
+	resetFIFO := false
+	for _, ch := range changes {
+		if err := d.writeConfig(ch.Reg, ch.New); err != nil {
+			return fmt.Errorf("max30102: could not apply configuration: %w", err)
+		}
+		switch ch.Reg {
+		case ModeCfg, MultiLedModeS2S1, MultiLedModeS4S3:
+			resetFIFO = true
+		}
+	}
+	if !resetFIFO {
+		return nil
+	}
+
+	if err := d.restartFIFO(); err != nil {
+		return fmt.Errorf("max30102: could not apply configuration: %w", err)
+	}
+
+	return nil
+}
+
+// Diff returns the registers that differ from configuration a to b.
+func Diff(a, b Config) []Change {
+	ra, rb := a.encode(), b.encode()
+
+	var changes []Change
+	for i, reg := range configRegs {
+		if ra[i] != rb[i] {
+			changes = append(changes, Change{Reg: reg, Old: ra[i], New: rb[i]})
+		}
+	}
+
+	return changes
+}
+
+func (c Config) String() string {
+	regs := c.encode()
+	s := make([]string, len(configRegs))
+	for i, reg := range configRegs {
+		s[i] = regName(reg) + ": " + describe(reg, regs[i])
+	}
+
+	return strings.Join(s, "\n")
+}
+
+// encode returns the value of each register of configRegs. The reset bit is
+// never set.
+func (c Config) encode() [len(configRegs)]byte {
+	mode := c.Mode
+	mode.Reset = false
+
+	return [...]byte{
+		c.Int1.Encode(),
+		c.Int2.Encode(),
+		c.FIFO.Encode(),
+		mode.Encode(),
+		c.SpO2.Encode(),
+		pulseAmp(c.RedPulseAmp),
+		pulseAmp(c.IRPulseAmp),
+		pulseAmp(c.GreenPulseAmp),
+		(c.Slots[1]&slotMask)<<4 | c.Slots[0]&slotMask,
+		(c.Slots[3]&slotMask)<<4 | c.Slots[2]&slotMask,
+	}
+}
+
+func decodeConfig(regs [len(configRegs)]byte) Config {
+	var c Config
+	c.Int1.Decode(regs[0])
+	c.Int2.Decode(regs[1])
+	c.FIFO.Decode(regs[2])
+	c.Mode.Decode(regs[3])
+	c.SpO2.Decode(regs[4])
+	c.RedPulseAmp = float64(regs[5]) / 5
+	c.IRPulseAmp = float64(regs[6]) / 5
+	c.GreenPulseAmp = float64(regs[7]) / 5
+	c.Slots = [maxSlots]byte{
+		regs[8] & slotMask, regs[8] >> 4 & slotMask,
+		regs[9] & slotMask, regs[9] >> 4 & slotMask,
+	}
+
+	return c
+}
+
+// pulseAmp returns the register value of a pulse amplitude in mA.
+func pulseAmp(current float64) byte {
+	if current > 51 {
+		current = 51
+	}
+	if current < 0 {
+		current = 0
+	}
+	// Amplitudes read with Config are multiples of 0.2 that could be off by
+	// a rounding error, so round them before truncating.
+	return byte(current*5 + 1e-9)
+}
+
+func regName(reg byte) string {
+	switch reg {
+	case IntEna1:
+		return "IntEna1"
+	case IntEna2:
+		return "IntEna2"
+	case FIFOCfg:
+		return "FIFOCfg"
+	case ModeCfg:
+		return "ModeCfg"
+	case SpO2Cfg:
+		return "SpO2Cfg"
+	case Led1PA:
+		return "Led1PA"
+	case Led2PA:
+		return "Led2PA"
+	case Led3PA:
+		return "Led3PA"
+	case MultiLedModeS2S1:
+		return "MultiLedModeS2S1"
+	case MultiLedModeS4S3:
+		return "MultiLedModeS4S3"
+	}
+	return fmt.Sprintf("%#x", reg)
+}
+
+// describe returns a human-readable value of a configuration register.
+func describe(reg, b byte) string {
+	var r Register
+	switch reg {
+	case IntEna1:
+		r = &IntEnable1{}
+	case IntEna2:
+		r = &IntEnable2{}
+	case FIFOCfg:
+		r = &FIFOConfig{}
+	case ModeCfg:
+		r = &ModeConfig{}
+	case SpO2Cfg:
+		r = &SpO2Config{}
+	case Led1PA, Led2PA, Led3PA:
+		return fmt.Sprintf("%.1fmA", float64(b)/5)
+	case MultiLedModeS2S1, MultiLedModeS4S3:
+		return fmt.Sprintf("%s,%s", slotName(b&slotMask), slotName(b>>4&slotMask))
+	default:
+		return fmt.Sprintf("%#x", b)
+	}
+	r.Decode(b)
+
+	return r.String()
+}
+
+func slotName(s byte) string {
+	switch s {
+	case SlotNone:
+		return "none"
+	case SlotRed:
+		return "red"
+	case SlotIR:
+		return "IR"
+	case SlotGreen:
+		return "green"
+	}
+	return fmt.Sprintf("%#x", s)
+}
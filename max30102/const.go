@@ -15,6 +15,7 @@ const (
 	SpO2Cfg          = 0x0A
 	Led1PA           = 0x0C
 	Led2PA           = 0x0D
+	Led3PA           = 0x0E
 	MultiLedModeS2S1 = 0x11
 	MultiLedModeS4S3 = 0x12
 	TempInt          = 0x1F
@@ -48,8 +49,6 @@ const (
 	ModeHR       byte = 0b010
 	ModeSpO2     byte = 0b011
 	ModeMultiLed byte = 0b111
-	modeMask     byte = 0b1111_1000
-	modeSHDN     byte = (1 << 7)
 
 	ResetControl = 0b0100_0000
 )
@@ -64,8 +63,6 @@ const (
 	SR1000
 	SR1600
 	SR3200
-
-	srMask byte = 0b1_11_000_11
 )
 
 // LED Pulse Width Control
@@ -74,16 +71,42 @@ const (
 	PW118
 	PW215
 	PW411
+)
+
+// SpO2 ADC Range Control, full scale in nA
+const (
+	ADC2048 = (iota << 5)
+	ADC4096
+	ADC8192
+	ADC16384
+)
+
+// Sample Averaging
+const (
+	Avg1 = (iota << 5)
+	Avg2
+	Avg4
+	Avg8
+	Avg16
+	Avg32
+)
+
+// Multi-LED mode slot control. Each slot can be driven by one LED or be
+// disabled. Only the MAX30101 and MAX30105 have a green LED.
+const (
+	SlotNone byte = iota
+	SlotRed
+	SlotIR
+	SlotGreen
 
-	pwMask byte = 0b1_11_111_00
+	slotMask byte = 0b0111
+	maxSlots      = 4
 )
 
-// masks
 const (
-	fifoFullMask byte = 0b111_1_0000
+	fifoDepth = 32
 )
 
 const (
-	maxADC  = (1 << 18) - 1
 	halfADC = (1 << 17) - 1
 )
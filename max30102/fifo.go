@@ -0,0 +1,238 @@
+package max30102
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Batch defines the samples read from the FIFO in a single burst.
+type Batch struct {
+	// LEDs holds the values of each LED, in the order returned by Slots,
+	// normalized from 0.0 to 1.0. LEDs[ch][i] is the value of channel ch in
+	// sample i.
+	LEDs [][]float64
+	// Lost is the number of samples lost because the FIFO was full before
+	// the batch was read. The samples of a batch are contiguous. With FIFO
+	// rollover (see Rollover), the oldest samples are overwritten, so the
+	// lost samples come before the batch. Otherwise, new samples are
+	// dropped, so the lost samples come after the batch, before the next
+	// one. The device counts up to 31 lost samples.
+	Lost int
+}
+
+// Len returns the number of samples of the batch.
+func (b Batch) Len() int {
+	if len(b.LEDs) == 0 {
+		return 0
+	}
+	return len(b.LEDs[0])
+}
+
+// Gap reports whether samples were lost while reading the batch (see Lost).
+func (b Batch) Gap() bool {
+	return b.Lost > 0
+}
+
+// Raw defines the samples read from the FIFO in a single burst, as ADC
+// counts.
+type Raw struct {
+	// Counts holds the counts of each LED, in the order returned by Slots.
+	// Counts[ch][i] is the count of channel ch in sample i, from 0 to
+	// 1<<Bits - 1.
+	Counts [][]uint32
+	// Bits is the resolution of the ADC, set by the pulse width.
+	Bits int
+	// FullScale is the full scale of the ADC in nA, set by ADCRange.
+	FullScale int
+	// Lost is the number of samples lost (see Batch).
+	Lost int
+}
+
+// Len returns the number of samples of the batch.
+func (r Raw) Len() int {
+	if len(r.Counts) == 0 {
+		return 0
+	}
+	return len(r.Counts[0])
+}
+
+// Normalized returns a count normalized from 0.0 to 1.0 of the full scale.
+func (r Raw) Normalized(count uint32) float64 {
+	return float64(count) / float64(uint32(1)<<r.Bits-1)
+}
+
+// Current returns the photodiode current of a count in nA.
+func (r Raw) Current(count uint32) float64 {
+	return r.Normalized(count) * float64(r.FullScale)
+}
+
+// Batch returns the samples normalized from 0.0 to 1.0.
+func (r Raw) Batch() Batch {
+	b := Batch{
+		LEDs: make([][]float64, len(r.Counts)),
+		Lost: r.Lost,
+	}
+	for ch, counts := range r.Counts {
+		b.LEDs[ch] = make([]float64, len(counts))
+		for i, c := range counts {
+			b.LEDs[ch][i] = r.Normalized(c)
+		}
+	}
+
+	return b
+}
+
+// Rollover reports whether the FIFO overwrites its oldest samples when it is
+// full (see FIFORollover).
+func (d *Device) Rollover() bool {
+	var c FIFOConfig
+	c.Decode(d.shadow[FIFOCfg])
+	return c.Rollover
+}
+
+// ReadFIFO reads all the samples in the FIFO without waiting, using one
+// transaction for the FIFO pointers and one for the samples. Unlike
+// IRRedBatch, no sample is discarded. The batch is empty if the FIFO is
+// empty.
+func (d *Device) ReadFIFO() (Batch, error) {
+	r, err := d.ReadRaw()
+	if err != nil {
+		return Batch{}, err
+	}
+
+	return r.Batch(), nil
+}
+
+// ReadFIFOContext reads all the samples in the FIFO as ReadFIFO, waiting
+// for the AlmostFull flag if there are less samples than set by
+// AlmostFullValue. It returns an error if ctx is done before.
+func (d *Device) ReadFIFOContext(ctx context.Context) (Batch, error) {
+	r, err := d.ReadRawContext(ctx)
+	if err != nil {
+		return Batch{}, err
+	}
+
+	return r.Batch(), nil
+}
+
+// ReadRaw reads all the samples in the FIFO as ReadFIFO, as ADC counts.
+func (d *Device) ReadRaw() (Raw, error) {
+	if len(d.slots) == 0 {
+		return Raw{}, ErrNoSlots
+	}
+
+	n, lost, err := d.fifoLevel(false)
+	if err != nil {
+		return Raw{}, err
+	}
+
+	return d.readBurst(n, lost)
+}
+
+// ReadRawContext reads all the samples in the FIFO as ReadFIFOContext, as ADC
+// counts.
+func (d *Device) ReadRawContext(ctx context.Context) (Raw, error) {
+	if len(d.slots) == 0 {
+		return Raw{}, ErrNoSlots
+	}
+
+	n, lost, err := d.fifoLevel(false)
+	if err != nil {
+		return Raw{}, err
+	}
+	if n < d.batchSize() {
+		// Without the INT pin, sleep until the batch should be ready
+		// instead of polling the bus all along.
+		if d.irq == nil {
+			t := time.NewTimer(time.Duration(d.batchSize()-n) * d.SamplePeriod())
+			select {
+			case <-t.C:
+			case <-ctx.Done():
+				t.Stop()
+				return Raw{}, fmt.Errorf("max30102: error waiting for almost full interrupt: %w",
+					&WaitError{Reg: IntStat1, Flag: AlmostFull, Bit: 1, Err: ctxErr(ctx)})
+			}
+		}
+		if err := d.waitUntil(ctx, IntStat1, AlmostFull, 1); err != nil {
+			return Raw{}, fmt.Errorf("max30102: error waiting for almost full interrupt: %w", err)
+		}
+		if n, lost, err = d.fifoLevel(true); err != nil {
+			return Raw{}, err
+		}
+	}
+
+	return d.readBurst(n, lost)
+}
+
+// fifoLevel returns the number of samples in the FIFO and the number of
+// samples lost. When the pointers are equal, the FIFO is either empty or
+// full: it is full if samples were lost, or if almostFull is set (the
+// AlmostFull flag was just seen while waiting). A raised AlmostFull flag is
+// not enough, as it is not cleared by reading the FIFO and can be left over
+// from a previous batch.
+func (d *Device) fifoLevel(almostFull bool) (n, lost int, err error) {
+	p, err := d.ReadBytes(FIFOWrPtr, 3)
+	if err != nil {
+		return 0, 0, fmt.Errorf("max30102: could not read FIFO pointers: %w", err)
+	}
+	wr, ovf, rd := p[0], p[1], p[2]
+
+	n = (int(wr) + fifoDepth - int(rd)) % fifoDepth
+	if n != 0 {
+		return n, int(ovf), nil
+	}
+	if ovf > 0 || almostFull {
+		return fifoDepth, int(ovf), nil
+	}
+
+	if err := d.clearStatus(); err != nil {
+		return 0, 0, err
+	}
+
+	return 0, 0, nil
+}
+
+// clearStatus reads IntStat1 to clear its flags, such as a left over
+// AlmostFull flag. It returns ErrDeviceReset if the PowerReady flag is
+// raised.
+func (d *Device) clearStatus() error {
+	state, err := d.Read(IntStat1)
+	if err != nil {
+		return fmt.Errorf("max30102: could not read interrupt status: %w", err)
+	}
+	if state&PowerReady != 0 {
+		return ErrDeviceReset
+	}
+
+	return nil
+}
+
+// readBurst reads n samples from the FIFO in a single transaction.
+func (d *Device) readBurst(n, lost int) (Raw, error) {
+	r := Raw{
+		Counts:    make([][]uint32, len(d.slots)),
+		Bits:      d.Resolution(),
+		FullScale: d.FullScale(),
+		Lost:      lost,
+	}
+	for ch := range r.Counts {
+		r.Counts[ch] = make([]uint32, n)
+	}
+	if n == 0 {
+		return r, nil
+	}
+
+	size := d.sampleSize()
+	bytes, err := d.readFIFOBytes(n * size)
+	if err != nil {
+		return Raw{}, fmt.Errorf("max30102: could not read FIFO: %w", err)
+	}
+	for i := 0; i < n; i++ {
+		for ch := range r.Counts {
+			r.Counts[ch][i] = counts(bytes[i*size:], ch, r.Bits)
+		}
+	}
+
+	return r, nil
+}
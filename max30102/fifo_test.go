@@ -0,0 +1,53 @@
+package max30102_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cgxeiji/max3010x/emulator"
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+func TestReadFIFOAfterDrain(t *testing.T) {
+	tests := []struct {
+		name    string
+		advance time.Duration
+		want    int
+	}{
+		{"empty", 0, 0},
+		{"one sample", 10 * time.Millisecond, 1},
+		{"half full", 160 * time.Millisecond, 16},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := emulator.NewManualClock(time.Now())
+			d, err := max30102.NewWithBus(emulator.New(
+				emulator.Constant(0.25, 0.5),
+				emulator.WithClock(clk),
+			))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+
+			// Overflow the FIFO, raising the AlmostFull flag, and drain it.
+			clk.Advance(time.Second)
+			b, err := d.ReadFIFO()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if b.Len() != 32 {
+				t.Fatalf("ReadFIFO() on a full FIFO returned %d samples, want 32", b.Len())
+			}
+
+			clk.Advance(tt.advance)
+			if b, err = d.ReadFIFO(); err != nil {
+				t.Fatal(err)
+			}
+			if b.Len() != tt.want {
+				t.Errorf("ReadFIFO() after draining returned %d samples, want %d", b.Len(), tt.want)
+			}
+		})
+	}
+}
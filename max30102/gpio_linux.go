@@ -0,0 +1,60 @@
+//go:build linux && !tinygo
+// +build linux,!tinygo
+
+package max30102
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// gpioEventRequest mirrors struct gpioevent_request of the Linux GPIO
+// character device.
+type gpioEventRequest struct {
+	LineOffset    uint32
+	HandleFlags   uint32
+	EventFlags    uint32
+	ConsumerLabel [32]byte
+	Fd            int32
+}
+
+const (
+	gpioHandleRequestInput      = (1 << 0)
+	gpioEventRequestFallingEdge = (1 << 1)
+
+	// _IOWR(0xB4, 0x04, struct gpioevent_request)
+	gpioGetLineEventIoctl = (3 << 30) | (uintptr(unsafe.Sizeof(gpioEventRequest{})) << 16) | (0xB4 << 8) | 0x04
+)
+
+// OpenGPIO requests falling edge events on a line of a GPIO chip (e.g.
+// "/dev/gpiochip0") connected to the INT pin of the device, and returns an
+// Interrupt that can be used with InterruptPin.
+func OpenGPIO(chip string, line uint32) (*EdgeReader, error) {
+	f, err := os.Open(chip)
+	if err != nil {
+		return nil, fmt.Errorf("max30102: could not open GPIO chip: %w", err)
+	}
+	defer f.Close()
+
+	req := gpioEventRequest{
+		LineOffset:  line,
+		HandleFlags: gpioHandleRequestInput,
+		EventFlags:  gpioEventRequestFallingEdge,
+	}
+	copy(req.ConsumerLabel[:], "max30102")
+
+	_, _, errno := syscall.Syscall(
+		syscall.SYS_IOCTL,
+		f.Fd(),
+		gpioGetLineEventIoctl,
+		uintptr(unsafe.Pointer(&req)),
+	)
+	if errno != 0 {
+		return nil, fmt.Errorf("max30102: could not request events on GPIO line %d: %w", line, errno)
+	}
+
+	name := fmt.Sprintf("%s:%d", chip, line)
+	return NewEdgeReader(os.NewFile(uintptr(req.Fd), name)), nil
+}
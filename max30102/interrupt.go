@@ -0,0 +1,72 @@
+package max30102
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Interrupt waits for the device to assert its active-low INT pin.
+type Interrupt interface {
+	// Wait blocks until a falling edge is detected on the INT pin.
+	Wait() error
+	// Close releases the resources used by the interrupt.
+	Close() error
+}
+
+// Layout of struct gpioevent_data of the Linux GPIO character device: a u64
+// timestamp followed by a u32 event ID, padded to the alignment of the u64.
+// The struct is 16 bytes on 64-bit targets and on ARM EABI (e.g. a 32-bit
+// Raspberry Pi OS), so its size is fixed instead of taken from a Go struct,
+// which is only 12 bytes on GOARCH=arm.
+const (
+	gpioEventSize = 16
+	gpioEventID   = 8
+
+	gpioEventFallingEdge = 0x02
+)
+
+// EdgeReader is an Interrupt that reads line events with the layout of the
+// Linux GPIO character device (struct gpioevent_data) from a reader, such as
+// the file returned by OpenGPIO or one end of a pipe.
+type EdgeReader struct {
+	r   io.ReadCloser
+	buf []byte
+}
+
+// NewEdgeReader returns a new EdgeReader that reads line events from r.
+func NewEdgeReader(r io.ReadCloser) *EdgeReader {
+	return &EdgeReader{
+		r:   r,
+		buf: make([]byte, gpioEventSize),
+	}
+}
+
+// Wait blocks until a falling edge event is read. Rising edge events are
+// ignored.
+func (e *EdgeReader) Wait() error {
+	for {
+		if _, err := io.ReadFull(e.r, e.buf); err != nil {
+			return fmt.Errorf("max30102: could not read GPIO event: %w", err)
+		}
+		if binary.LittleEndian.Uint32(e.buf[gpioEventID:]) == gpioEventFallingEdge {
+			return nil
+		}
+	}
+}
+
+// Close closes the underlying reader.
+func (e *EdgeReader) Close() error {
+	return e.r.Close()
+}
+
+// InterruptPin makes the device wait for its INT pin instead of polling the
+// interrupt status registers over the bus. The interrupt is closed when the
+// device is closed. Setting it to nil goes back to polling.
+func InterruptPin(irq Interrupt) Option {
+	return func(d *Device) (Option, error) {
+		old := d.irq
+		d.irq = irq
+		return InterruptPin(old), nil
+	}
+}
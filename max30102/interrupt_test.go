@@ -0,0 +1,169 @@
+package max30102_test
+
+import (
+	"encoding/binary"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/cgxeiji/max3010x/emulator"
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+const (
+	risingEdge  = 0x01
+	fallingEdge = 0x02
+)
+
+// event returns a line event with the layout of struct gpioevent_data on
+// 64-bit and ARM EABI targets.
+func event(id uint32) []byte {
+	b := make([]byte, 16)
+	binary.LittleEndian.PutUint64(b, uint64(time.Now().UnixNano()))
+	binary.LittleEndian.PutUint32(b[8:], id)
+	return b
+}
+
+func TestEdgeReader(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    [][]byte
+		wantErr bool
+	}{
+		{name: "falling edge", data: [][]byte{event(fallingEdge)}},
+		{name: "rising edge ignored", data: [][]byte{event(risingEdge), event(risingEdge), event(fallingEdge)}},
+		{name: "closed", wantErr: true},
+		{name: "short event", data: [][]byte{event(fallingEdge)[:12]}, wantErr: true},
+		{name: "only rising edges", data: [][]byte{event(risingEdge)}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			r, w, err := os.Pipe()
+			if err != nil {
+				t.Fatal(err)
+			}
+			e := max30102.NewEdgeReader(r)
+			defer e.Close()
+
+			go func() {
+				for _, b := range tt.data {
+					w.Write(b)
+				}
+				if tt.wantErr {
+					w.Close()
+				}
+			}()
+			defer w.Close()
+
+			err = e.Wait()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Wait() = %v, want error: %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// countingBus counts the transactions on a bus.
+type countingBus struct {
+	max30102.Bus
+	txs int
+}
+
+func (b *countingBus) Read(reg byte) (byte, error) {
+	b.txs++
+	return b.Bus.Read(reg)
+}
+
+func (b *countingBus) ReadBytes(reg byte, n int) ([]byte, error) {
+	b.txs++
+	return b.Bus.ReadBytes(reg, n)
+}
+
+func (b *countingBus) Write(reg, data byte) error {
+	b.txs++
+	return b.Bus.Write(reg, data)
+}
+
+func TestInterruptPin(t *testing.T) {
+	bus := &countingBus{Bus: emulator.New(emulator.Pulse(72, 97))}
+	d, err := max30102.NewWithBus(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Options(max30102.InterruptPin(max30102.NewEdgeReader(r))); err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	// Assert the INT pin at each new sample (100 samples/s).
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		defer w.Close()
+		tick := time.NewTicker(10 * time.Millisecond)
+		defer tick.Stop()
+		for {
+			select {
+			case <-tick.C:
+				if _, err := w.Write(event(fallingEdge)); err != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	bus.txs = 0
+	for i := 0; i < 10; i++ {
+		if _, _, err := d.IRRed(); err != nil {
+			t.Fatalf("IRRed() = %v", err)
+		}
+	}
+	// Each sample needs a few transactions at most, instead of polling.
+	if bus.txs > 50 {
+		t.Errorf("IRRed() made %d transactions for 10 samples, want at most 50", bus.txs)
+	}
+}
+
+func TestTemperatureInterrupt(t *testing.T) {
+	tests := []struct {
+		name string
+		ena  byte
+	}{
+		{"die temperature only", max30102.DieTempReady},
+		{"with FIFO interrupts", max30102.DieTempReady | max30102.NewFIFOData | max30102.AlmostFull},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := emulator.New(emulator.Pulse(72, 97))
+			d, err := max30102.NewWithBus(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+			if _, err := d.Options(
+				max30102.InterruptEnable(tt.ena),
+				max30102.InterruptPin(e.Interrupt()),
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			// Unread FIFO flags must not keep the INT pin asserted.
+			time.Sleep(50 * time.Millisecond)
+			for i := 0; i < 3; i++ {
+				if _, err := d.Temperature(); err != nil {
+					t.Fatalf("Temperature() = %v", err)
+				}
+			}
+		})
+	}
+}
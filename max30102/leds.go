@@ -0,0 +1,186 @@
+package max30102
+
+import (
+	"context"
+	"fmt"
+)
+
+// updateSlots reads the mode and slot configuration of the device to know
+// which LED is stored in each channel of a FIFO sample.
+func (d *Device) updateSlots() error {
+	mode, err := d.Read(ModeCfg)
+	if err != nil {
+		return fmt.Errorf("could not get mode: %w", err)
+	}
+
+	switch mode & modeBits {
+	case ModeHR:
+		d.slots = []byte{SlotRed}
+	case ModeSpO2:
+		d.slots = []byte{SlotRed, SlotIR}
+	case ModeMultiLed:
+		d.slots = d.slots[:0]
+		for _, reg := range []byte{MultiLedModeS2S1, MultiLedModeS4S3} {
+			cfg, err := d.Read(reg)
+			if err != nil {
+				return fmt.Errorf("could not get LED slots: %w", err)
+			}
+			for _, s := range []byte{cfg & slotMask, cfg >> 4 & slotMask} {
+				if s == SlotNone {
+					return nil
+				}
+				d.slots = append(d.slots, s)
+			}
+		}
+	default:
+		d.slots = nil
+	}
+
+	return nil
+}
+
+// Slots returns the LED stored in each channel of a FIFO sample for the
+// current mode (e.g. SlotRed and SlotIR in SpO2 mode).
+func (d *Device) Slots() []byte {
+	s := make([]byte, len(d.slots))
+	copy(s, d.slots)
+	return s
+}
+
+// sampleSize returns the number of bytes of a FIFO sample.
+func (d *Device) sampleSize() int {
+	return 3 * len(d.slots)
+}
+
+// slot returns the channel index of led in a FIFO sample, or -1 if the LED is
+// not sampled.
+func (d *Device) slot(led byte) int {
+	for i, s := range d.slots {
+		if s == led {
+			return i
+		}
+	}
+	return -1
+}
+
+// Resolution returns the resolution of the ADC in bits, set by the pulse
+// width: from 15 bits at PW69 to 18 bits at PW411.
+func (d *Device) Resolution() int {
+	var c SpO2Config
+	c.Decode(d.shadow[SpO2Cfg])
+	return 15 + int(c.PulseWidth)
+}
+
+// FullScale returns the full scale of the ADC in nA, set by ADCRange.
+func (d *Device) FullScale() int {
+	var c SpO2Config
+	c.Decode(d.shadow[SpO2Cfg])
+	return adcRanges[c.ADCRange>>5]
+}
+
+// counts returns the ADC counts of channel ch in a FIFO sample, at a
+// resolution of bits. The FIFO data is left-justified, so the bits below the
+// resolution, which hold no data, are dropped.
+func counts(bytes []byte, ch, bits int) uint32 {
+	const msbMask byte = 0b0000_0011
+
+	b := bytes[3*ch:]
+	c := uint32(b[0]&msbMask)<<16 |
+		uint32(b[1])<<8 |
+		uint32(b[2])
+	return c >> (18 - bits)
+}
+
+// decode returns the normalized (0.0 to 1.0) value of channel ch in a FIFO
+// sample, at a resolution of bits.
+func decode(bytes []byte, ch, bits int) float64 {
+	if ch < 0 {
+		return 0
+	}
+	return float64(counts(bytes, ch, bits)) / float64(uint32(1)<<bits-1)
+}
+
+// LEDs returns the value of each LED sampled in a FIFO sample, in the order
+// returned by Slots. The values are normalized from 0.0 to 1.0. It times out
+// as IRRed.
+func (d *Device) LEDs() ([]float64, error) {
+	ctx, cancel := d.sampleTimeout(1)
+	defer cancel()
+
+	return d.LEDsContext(ctx)
+}
+
+// LEDsContext returns the value of each LED (see LEDs), or an error if ctx is
+// done before a sample is taken.
+func (d *Device) LEDsContext(ctx context.Context) ([]float64, error) {
+	if len(d.slots) == 0 {
+		return nil, ErrNoSlots
+	}
+
+	err := d.waitUntil(ctx, IntStat1, NewFIFOData, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, err := d.readFIFO()
+	if err != nil {
+		return nil, err
+	}
+
+	bits := d.Resolution()
+	leds := make([]float64, len(d.slots))
+	for ch := range leds {
+		leds[ch] = decode(bytes, ch, bits)
+	}
+
+	return leds, nil
+}
+
+// LEDsBatch returns a batch of values of each LED, in the order returned by
+// Slots, based on the AlmostFull flag (see IRRedBatch).
+func (d *Device) LEDsBatch() ([][]float64, error) {
+	ctx, cancel := d.sampleTimeout(d.batchSize())
+	defer cancel()
+
+	return d.LEDsBatchContext(ctx)
+}
+
+// LEDsBatchContext returns a batch of values of each LED (see LEDsBatch), or
+// an error if ctx is done before the batch is taken.
+func (d *Device) LEDsBatchContext(ctx context.Context) ([][]float64, error) {
+	if len(d.slots) == 0 {
+		return nil, ErrNoSlots
+	}
+
+	err := d.drain()
+	if err != nil {
+		return nil, fmt.Errorf("max30102: could not empty FIFO: %w", err)
+	}
+	err = d.waitUntil(ctx, IntStat1, AlmostFull, 1)
+	if err != nil {
+		return nil, fmt.Errorf("max30102: error waiting for almost full interrupt: %w", err)
+	}
+
+	n, err := d.available()
+	if err != nil {
+		return nil, fmt.Errorf("max30102: error reading available data: %w", err)
+	}
+
+	bits := d.Resolution()
+	leds := make([][]float64, len(d.slots))
+	for ch := range leds {
+		leds[ch] = make([]float64, n)
+	}
+	for i := 0; i < n; i++ {
+		bytes, err := d.readFIFO()
+		if err != nil {
+			return nil, err
+		}
+
+		for ch := range leds {
+			leds[ch][i] = decode(bytes, ch, bits)
+		}
+	}
+
+	return leds, nil
+}
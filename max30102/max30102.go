@@ -1,43 +1,44 @@
 package max30102
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"time"
-
-	"github.com/cgxeiji/serial"
 )
 
 var (
 	// ErrNotDevice throws an error when the device part ID does not match a
 	// MAX30102 signature (0x15).
 	ErrNotDevice error = errors.New("max30102: part ID does not match (0x15)")
+	// ErrNoSlots throws an error when reading the FIFO while the device is not
+	// set to sample any LED.
+	ErrNoSlots error = errors.New("max30102: no LED is being sampled")
 )
 
 // Device defines a MAX30102 device.
 type Device struct {
-	i2c *serial.I2C
-}
+	bus   Bus
+	irq   Interrupt
+	slots []byte
+	buf   []byte
 
-// New returns a new MAX30102 device. By default, this sets the LED pulse
-// amplitude to 2.4mA, with a pulse width of 411us and a sample rate of 100
-// samples/s.
-//
-// Argument "busName" can be used to specify the exact bus to use ("/dev/i2c-2", "I2C2", "2").
-// Argument "addr" can be used to specify alternative address if default (0x57) is unavailable and changed.
-// If "busName" argument is specified as an empty string "" the first available bus will be used.
-func New(busName string, addr uint16) (*Device, error) {
-	if addr == 0 {
-		addr = Addr
-	}
+	verify bool
 
-	i2c, err := serial.NewI2C(busName, addr)
-	if err != nil {
-		return nil, fmt.Errorf("max30102: could not initialize I2C: %w", err)
-	}
+	// shadow holds the last value written to each configuration register.
+	shadow map[byte]byte
+	// edge holds a wait for the INT pin interrupted by a context.
+	edge chan error
+}
 
+// NewWithBus returns a new MAX30102 device that communicates through bus. The
+// device is initialized with the same defaults as New: the LED pulse amplitude
+// is set to 2.8mA, with a pulse width of 411us and a sample rate of 100
+// samples/s. The bus is owned by
+// the device and closed when the device is closed.
+func NewWithBus(bus Bus) (*Device, error) {
 	d := &Device{
-		i2c: i2c,
+		bus: bus,
 	}
 
 	part, err := d.Read(RegPartID)
@@ -48,6 +49,9 @@ func New(busName string, addr uint16) (*Device, error) {
 		return nil, ErrNotDevice
 	}
 
+	if err := d.clearPowerReady(); err != nil {
+		return nil, fmt.Errorf("max30102: could not reset device: %w", err)
+	}
 	err = d.Reset()
 	if err != nil {
 		return nil, fmt.Errorf("max30102: could not reset device: %w", err)
@@ -63,7 +67,9 @@ func New(busName string, addr uint16) (*Device, error) {
 	); err != nil {
 		return nil, fmt.Errorf("max30102: could not initialize device: %w", err)
 	}
-	d.drain()
+	if err := d.drain(); err != nil {
+		return nil, fmt.Errorf("max30102: could not empty FIFO: %w", err)
+	}
 
 	return d, nil
 }
@@ -71,7 +77,10 @@ func New(busName string, addr uint16) (*Device, error) {
 // Close closes the device and cleans after itself.
 func (d *Device) Close() {
 	d.Shutdown()
-	d.i2c.Close()
+	d.bus.Close()
+	if d.irq != nil {
+		d.irq.Close()
+	}
 }
 
 // RevID returns the revision ID of the device.
@@ -83,32 +92,6 @@ func (d *Device) RevID() (byte, error) {
 	return rev, nil
 }
 
-func (d *Device) waitUntil(reg, flag byte, bit byte) error {
-	switch bit {
-	case 1:
-		for {
-			state, err := d.Read(reg)
-			if err != nil {
-				return fmt.Errorf("could not wait for %v in %v to be %v", flag, reg, bit)
-			} else if state&flag != 0 {
-				//fmt.Printf("%#x = %#b\n", reg, state)
-				return nil
-			}
-		}
-	case 0:
-		for {
-			if state, err := d.Read(reg); err != nil {
-				return fmt.Errorf("could not wait for %v in %v to be %v", flag, reg, bit)
-			} else if state&flag == 0 {
-				//fmt.Printf("%#x = %#b\n", reg, state)
-				return nil
-			}
-		}
-	}
-
-	return fmt.Errorf("invalid bit %v, it should be 1 or 0", bit)
-}
-
 func (d *Device) tempEnable() error {
 	if err := d.Write(TempCfg, TempEna); err != nil {
 		return fmt.Errorf("max30102: could not enable temperature: %w", err)
@@ -124,12 +107,28 @@ func (d *Device) tempReady() (bool, error) {
 	return (state & TempEna) == 0, nil
 }
 
-// Temperature returns the current temperature of the device.
+// Temperature returns the current temperature of the device. It times out
+// after 100ms.
 func (d *Device) Temperature() (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), tempTimeout)
+	defer cancel()
+
+	return d.TemperatureContext(ctx)
+}
+
+// TemperatureContext returns the current temperature of the device, or an
+// error if ctx is done before the temperature is measured.
+func (d *Device) TemperatureContext(ctx context.Context) (float64, error) {
 	if err := d.tempEnable(); err != nil {
 		return 0, err
 	}
-	if err := d.waitUntil(TempCfg, TempEna, 0); err != nil {
+	// With the die temperature interrupt enabled, the INT pin can be used
+	// to wait for the conversion.
+	if d.shadow[IntEna2]&DieTempReady != 0 {
+		if err := d.waitUntil(ctx, IntStat2, DieTempReady, 1); err != nil {
+			return 0, err
+		}
+	} else if err := d.waitUntil(ctx, TempCfg, TempEna, 0); err != nil {
 		return 0, err
 	}
 
@@ -148,55 +147,73 @@ func (d *Device) Temperature() (float64, error) {
 
 // Read reads a single byte from a register.
 func (d *Device) Read(reg byte) (byte, error) {
-	return d.i2c.Read(reg)
+	return d.bus.Read(reg)
 }
 
 // ReadBytes reads n bytes from a register.
 func (d *Device) ReadBytes(reg byte, n int) ([]byte, error) {
-	return d.i2c.ReadBytes(reg, n)
+	return d.bus.ReadBytes(reg, n)
 }
 
 // Write writes a byte to a register.
 func (d *Device) Write(reg, data byte) error {
-	return d.i2c.Write(reg, data)
+	return d.bus.Write(reg, data)
 }
 
 // Reset resets the device. All configurations, thresholds, and data registers
-// are reset to their power-on state.
+// are reset to their power-on state. It times out after 100ms.
 func (d *Device) Reset() error {
+	ctx, cancel := context.WithTimeout(context.Background(), resetTimeout)
+	defer cancel()
+
+	return d.ResetContext(ctx)
+}
+
+// ResetContext resets the device, or returns an error if ctx is done before
+// the reset completes.
+func (d *Device) ResetContext(ctx context.Context) error {
 	if err := d.Write(ModeCfg, ResetControl); err != nil {
 		return fmt.Errorf("max30102: could not reset: %w", err)
 	}
-	if err := d.waitUntil(ModeCfg, ResetControl, 0); err != nil {
+	if err := d.waitUntil(ctx, ModeCfg, ResetControl, 0); err != nil {
 		return fmt.Errorf("max30102: could not reset: %w", err)
 	}
+	d.slots = nil
+	d.shadow = nil
 
 	return nil
 }
 
 // IRRed returns the value of the red LED and IR LED. The values are normalized
-// from 0.0 to 1.0.
+// from 0.0 to 1.0. If an LED is not being sampled, its value is 0. It times
+// out if no sample is taken within the time expected from the sample rate.
 func (d *Device) IRRed() (ir, red float64, err error) {
-	const msbMask byte = 0b0000_0011
+	ctx, cancel := d.sampleTimeout(1)
+	defer cancel()
 
-	err = d.waitUntil(IntStat1, NewFIFOData, 1)
+	return d.IRRedContext(ctx)
+}
+
+// IRRedContext returns the value of the red LED and IR LED (see IRRed), or an
+// error if ctx is done before a sample is taken.
+func (d *Device) IRRedContext(ctx context.Context) (ir, red float64, err error) {
+	if len(d.slots) == 0 {
+		return 0, 0, ErrNoSlots
+	}
+
+	err = d.waitUntil(ctx, IntStat1, NewFIFOData, 1)
 	if err != nil {
 		return 0, 0, err
 	}
 
-	bytes, err := d.ReadBytes(FIFOData, 6)
+	bytes, err := d.readFIFO()
 	if err != nil {
 		return 0, 0, err
 	}
 
-	ir = float64(
-		int(bytes[3]&msbMask)<<16|
-			int(bytes[4])<<8|
-			int(bytes[5])) / maxADC
-	red = float64(
-		int(bytes[0]&msbMask)<<16|
-			int(bytes[1])<<8|
-			int(bytes[2])) / maxADC
+	bits := d.Resolution()
+	ir = decode(bytes, d.slot(SlotIR), bits)
+	red = decode(bytes, d.slot(SlotRed), bits)
 
 	return ir, red, nil
 }
@@ -204,16 +221,32 @@ func (d *Device) IRRed() (ir, red float64, err error) {
 // IRRedBatch returns a batch of IR and red LED values based on the AlmostFull
 // flag. The amount of data returned can be configured by setting the
 // AlmostFullValue leftover value, which is set to 0 by default. Therefore,
-// this function returns 32 samples by default.
+// this function returns 32 samples by default. If an LED is not being
+// sampled, its values are 0. It times out if the batch is not taken within
+// the time expected from the sample rate.
 func (d *Device) IRRedBatch() (ir, red []float64, err error) {
-	const maxADC = 262143
-	const msbMask byte = 0b0000_0011
+	ctx, cancel := d.sampleTimeout(d.batchSize())
+	defer cancel()
+
+	return d.IRRedBatchContext(ctx)
+}
+
+// IRRedBatchContext returns a batch of IR and red LED values (see
+// IRRedBatch), or an error if ctx is done before the batch is taken.
+func (d *Device) IRRedBatchContext(ctx context.Context) (ir, red []float64, err error) {
+	if len(d.slots) == 0 {
+		return nil, nil, ErrNoSlots
+	}
 
 	err = d.drain()
 	if err != nil {
 		return nil, nil, fmt.Errorf("max30102: could not empty FIFO: %w", err)
 	}
-	err = d.waitUntil(IntStat1, AlmostFull, 1)
+	// Reading the FIFO does not clear the AlmostFull flag.
+	if err := d.clearStatus(); err != nil {
+		return nil, nil, err
+	}
+	err = d.waitUntil(ctx, IntStat1, AlmostFull, 1)
 	if err != nil {
 		return nil, nil, fmt.Errorf("max30102: error waiting for almost full interrupt: %w", err)
 	}
@@ -223,25 +256,19 @@ func (d *Device) IRRedBatch() (ir, red []float64, err error) {
 		return nil, nil, fmt.Errorf("max30102: error reading available data: %w", err)
 	}
 
+	irCh := d.slot(SlotIR)
+	redCh := d.slot(SlotRed)
+	bits := d.Resolution()
 	ir = make([]float64, n)
 	red = make([]float64, n)
 	for i := 0; i < n; i++ {
-		bytes, err := d.ReadBytes(FIFOData, 6)
+		bytes, err := d.readFIFO()
 		if err != nil {
 			return nil, nil, err
 		}
 
-		irData := float64(
-			int(bytes[3]&msbMask)<<16|
-				int(bytes[4])<<8|
-				int(bytes[5])) / maxADC
-		redData := float64(
-			int(bytes[0]&msbMask)<<16|
-				int(bytes[1])<<8|
-				int(bytes[2])) / maxADC
-
-		ir[i] = irData
-		red[i] = redData
+		ir[i] = decode(bytes, irCh, bits)
+		red[i] = decode(bytes, redCh, bits)
 	}
 
 	return ir, red, nil
@@ -253,7 +280,7 @@ func (d *Device) drain() error {
 		return err
 	}
 	for i := 0; i < n; i++ {
-		_, err := d.ReadBytes(FIFOData, 6)
+		_, err := d.readFIFO()
 		if err != nil {
 			return err
 		}
@@ -264,11 +291,11 @@ func (d *Device) drain() error {
 func (d *Device) available() (int, error) {
 	wr, err := d.Read(FIFOWrPtr)
 	if err != nil {
-		return 0, nil
+		return 0, err
 	}
 	rd, err := d.Read(FIFORdPtr)
 	if err != nil {
-		return 0, nil
+		return 0, err
 	}
 
 	if wr == rd {
@@ -353,16 +380,14 @@ func mean(a []float64) float64 {
 
 // Shutdown sets the device into power-save mode.
 func (d *Device) Shutdown() error {
-	_, err := d.config(ModeCfg, ^modeSHDN, modeSHDN)
-
-	return err
+	var r ModeConfig
+	return d.update(&r, func() { r.Shutdown = true })
 }
 
 // Startup wakes the device from power-save mode.
 func (d *Device) Startup() error {
-	_, err := d.config(ModeCfg, ^modeSHDN, ^modeSHDN)
-
-	return err
+	var r ModeConfig
+	return d.update(&r, func() { r.Shutdown = false })
 }
 
 func (d *Device) debugRegister(reg byte) {
@@ -0,0 +1,299 @@
+package max30102_test
+
+import (
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/cgxeiji/max3010x/emulator"
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+// mockBus is a register map. Writing the reset bit clears the registers,
+// unless the bit is stuck.
+type mockBus struct {
+	regs     [256]byte
+	stuck    byte
+	readErr  map[byte]error
+	writeErr map[byte]error
+	writes   int
+}
+
+func newMockBus() *mockBus {
+	b := &mockBus{}
+	b.regs[max30102.RegPartID] = max30102.PartID
+	return b
+}
+
+func (b *mockBus) Read(reg byte) (byte, error) {
+	if err := b.readErr[reg]; err != nil {
+		return 0, err
+	}
+	return b.regs[reg] | b.stuckBits(reg), nil
+}
+
+func (b *mockBus) ReadBytes(reg byte, n int) ([]byte, error) {
+	r := make([]byte, n)
+	for i := range r {
+		v, err := b.Read(reg)
+		if err != nil {
+			return nil, err
+		}
+		r[i] = v
+		if reg != max30102.FIFOData {
+			reg++
+		}
+	}
+	return r, nil
+}
+
+func (b *mockBus) Write(reg, data byte) error {
+	if err := b.writeErr[reg]; err != nil {
+		return err
+	}
+	b.writes++
+	if reg == max30102.ModeCfg && data&max30102.ResetControl != 0 {
+		part := b.regs[max30102.RegPartID]
+		b.regs = [256]byte{}
+		b.regs[max30102.RegPartID] = part
+		return nil
+	}
+	b.regs[reg] = data
+	return nil
+}
+
+func (b *mockBus) Close() {}
+
+func (b *mockBus) stuckBits(reg byte) byte {
+	if reg == max30102.ModeCfg {
+		return b.stuck
+	}
+	return 0
+}
+
+func TestNewWithBus(t *testing.T) {
+	errBus := errors.New("bus error")
+
+	tests := []struct {
+		name    string
+		setup   func(b *mockBus)
+		wantErr error
+	}{
+		{name: "defaults"},
+		{
+			name:    "wrong part",
+			setup:   func(b *mockBus) { b.regs[max30102.RegPartID] = 0x11 },
+			wantErr: max30102.ErrNotDevice,
+		},
+		{
+			name:    "part ID read error",
+			setup:   func(b *mockBus) { b.readErr = map[byte]error{max30102.RegPartID: errBus} },
+			wantErr: errBus,
+		},
+		{
+			name:    "configuration write error",
+			setup:   func(b *mockBus) { b.writeErr = map[byte]error{max30102.SpO2Cfg: errBus} },
+			wantErr: errBus,
+		},
+		{
+			name:    "reset stuck",
+			setup:   func(b *mockBus) { b.stuck = max30102.ResetControl },
+			wantErr: max30102.ErrTimeout,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := newMockBus()
+			if tt.setup != nil {
+				tt.setup(bus)
+			}
+
+			d, err := max30102.NewWithBus(bus)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("NewWithBus() = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewWithBus() = %v", err)
+			}
+			defer d.Close()
+
+			for reg, want := range map[byte]byte{
+				max30102.ModeCfg: max30102.ModeSpO2,
+				max30102.SpO2Cfg: max30102.SR100 | max30102.PW411,
+				max30102.Led1PA:  14, // 2.8mA
+				max30102.Led2PA:  14,
+				max30102.IntEna1: max30102.NewFIFOData | max30102.AlmostFull,
+				max30102.FIFOCfg: 0,
+			} {
+				if got := bus.regs[reg]; got != want {
+					t.Errorf("register %#x = %#x, want %#x", reg, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opt  max30102.Option
+		reg  byte
+		want byte
+	}{
+		{"SampleRate", max30102.SampleRate(max30102.SR400), max30102.SpO2Cfg, max30102.SR400 | max30102.PW411},
+		{"PulseWidth", max30102.PulseWidth(max30102.PW69), max30102.SpO2Cfg, max30102.SR100 | max30102.PW69},
+		{"ADCRange", max30102.ADCRange(max30102.ADC16384), max30102.SpO2Cfg, max30102.ADC16384 | max30102.SR100 | max30102.PW411},
+		{"Mode", max30102.Mode(max30102.ModeHR), max30102.ModeCfg, max30102.ModeHR},
+		{"RedPulseAmp", max30102.RedPulseAmp(10), max30102.Led1PA, 50},
+		{"IRPulseAmp", max30102.IRPulseAmp(51), max30102.Led2PA, 0xFF},
+		{"SampleAveraging", max30102.SampleAveraging(max30102.Avg8), max30102.FIFOCfg, max30102.Avg8},
+		{"AlmostFullValue", max30102.AlmostFullValue(15), max30102.FIFOCfg, 15},
+		{"FIFORollover", max30102.FIFORollover(true), max30102.FIFOCfg, 1 << 4},
+		{"InterruptEnable", max30102.InterruptEnable(max30102.AlmostFull), max30102.IntEna1, max30102.AlmostFull},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := newMockBus()
+			d, err := max30102.NewWithBus(bus)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+			old := bus.regs[tt.reg]
+
+			undo, err := d.Options(tt.opt)
+			if err != nil {
+				t.Fatalf("Options() = %v", err)
+			}
+			if got := bus.regs[tt.reg]; got != tt.want {
+				t.Errorf("register %#x = %#x, want %#x", tt.reg, got, tt.want)
+			}
+
+			if _, err := d.Options(undo); err != nil {
+				t.Fatalf("Options(undo) = %v", err)
+			}
+			if got := bus.regs[tt.reg]; got != old {
+				t.Errorf("register %#x after undo = %#x, want %#x", tt.reg, got, old)
+			}
+		})
+	}
+}
+
+func TestInvalidOptions(t *testing.T) {
+	d, err := max30102.NewWithBus(newMockBus())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	for _, opt := range []max30102.Option{
+		max30102.PulseWidth(0x04),
+		max30102.SampleRate(0x01),
+		max30102.ADCRange(0x01),
+	} {
+		if _, err := d.Options(opt); !errors.Is(err, max30102.ErrInvalidSetting) {
+			t.Errorf("Options() = %v, want ErrInvalidSetting", err)
+		}
+	}
+}
+
+func TestRegisters(t *testing.T) {
+	bus := newMockBus()
+	d, err := max30102.NewWithBus(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	tests := []struct {
+		name string
+		r    max30102.Register
+		want byte
+	}{
+		{"ModeConfig", &max30102.ModeConfig{Shutdown: true, Mode: max30102.ModeMultiLed}, 0x87},
+		{"SpO2Config", &max30102.SpO2Config{ADCRange: max30102.ADC4096, SampleRate: max30102.SR200, PulseWidth: max30102.PW118}, 0x29},
+		{"FIFOConfig", &max30102.FIFOConfig{Averaging: max30102.Avg4, Rollover: true, AlmostFull: 4}, 0x54},
+		{"IntEnable2", &max30102.IntEnable2{DieTempReady: true}, max30102.DieTempReady},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := d.WriteRegister(tt.r); err != nil {
+				t.Fatalf("WriteRegister() = %v", err)
+			}
+			if got := bus.regs[tt.r.Addr()]; got != tt.want {
+				t.Errorf("register %#x = %#x, want %#x", tt.r.Addr(), got, tt.want)
+			}
+
+			bus.regs[tt.r.Addr()] = 0
+			if err := d.ReadRegister(tt.r); err != nil {
+				t.Fatalf("ReadRegister() = %v", err)
+			}
+			if got := tt.r.Encode(); got != 0 {
+				t.Errorf("ReadRegister() of 0 encodes to %#x, want 0", got)
+			}
+		})
+	}
+}
+
+func TestIRRedBatch(t *testing.T) {
+	d, err := max30102.NewWithBus(emulator.New(emulator.Constant(0.25, 0.5)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	// The AlmostFull flag raised while the FIFO fills up between two
+	// batches must not end the next one early.
+	for n := 0; n < 2; n++ {
+		time.Sleep(400 * time.Millisecond)
+		ir, red, err := d.IRRedBatch()
+		if err != nil {
+			t.Fatalf("IRRedBatch() = %v", err)
+		}
+		if len(ir) != 32 || len(red) != 32 {
+			t.Fatalf("IRRedBatch() returned %d IR and %d red values, want 32", len(ir), len(red))
+		}
+		for i := range ir {
+			if math.Abs(ir[i]-0.5) > 0.001 || math.Abs(red[i]-0.25) > 0.001 {
+				t.Fatalf("IRRedBatch()[%d] = %v, %v, want 0.5, 0.25", i, ir[i], red[i])
+			}
+		}
+	}
+}
+
+func TestCalibrate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("takes several batches of samples")
+	}
+
+	// At 2.8mA, IR reads 0.9 and red 0.6, so they need 1.5mA (rounded down
+	// to 1.4mA) and 2.0mA to read above 0.4.
+	d, err := max30102.NewWithBus(emulator.New(emulator.Constant(0.6, 0.9)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	if err := d.Calibrate(); err != nil {
+		t.Fatalf("Calibrate() = %v", err)
+	}
+	for reg, want := range map[byte]byte{
+		max30102.Led2PA: 7,  // 1.4mA
+		max30102.Led1PA: 10, // 2.0mA
+	} {
+		got, err := d.Read(reg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("register %#x = %d, want %d", reg, got, want)
+		}
+	}
+}
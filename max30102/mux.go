@@ -0,0 +1,145 @@
+package max30102
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+var (
+	// ErrMuxChannel throws an error when selecting a channel that does not
+	// exist on the multiplexer.
+	ErrMuxChannel error = errors.New("max30102: invalid multiplexer channel")
+)
+
+// Multiplexer constants
+const (
+	MuxAddr     = 0x70
+	muxChannels = 8
+)
+
+// Mux defines a TCA9548A I²C multiplexer. As every MAX3010x answers at the
+// same address, a multiplexer is needed to use several of them on one bus.
+// Buses returned by a Mux select their channel before each transaction, and
+// transactions of all the buses sharing a Mux are serialized.
+type Mux struct {
+	mu      sync.Mutex
+	ctrl    Bus
+	current int
+	refs    int
+	key     string
+}
+
+// muxes holds the multiplexers opened by OpenMux, so that they are shared.
+var muxes = struct {
+	sync.Mutex
+	open map[string]*Mux
+}{
+	open: make(map[string]*Mux),
+}
+
+// NewMux returns a new multiplexer controlled through ctrl, a bus that talks
+// to the address of the multiplexer. The control bus is closed when the
+// multiplexer and all the buses returned by it are closed.
+func NewMux(ctrl Bus) *Mux {
+	return &Mux{
+		ctrl:    ctrl,
+		current: -1,
+		refs:    1,
+	}
+}
+
+// Bus returns a bus that selects channel ch (0 to 7) of the multiplexer before
+// each transaction on bus. Closing the returned bus closes bus.
+func (m *Mux) Bus(ch int, bus Bus) (Bus, error) {
+	if ch < 0 || ch >= muxChannels {
+		return nil, fmt.Errorf("%w: %d", ErrMuxChannel, ch)
+	}
+
+	m.mu.Lock()
+	m.refs++
+	m.mu.Unlock()
+
+	return &muxBus{
+		mux: m,
+		ch:  ch,
+		bus: bus,
+	}, nil
+}
+
+// Close releases the multiplexer. The control bus is closed once all the
+// buses returned by the multiplexer are closed too.
+func (m *Mux) Close() {
+	muxes.Lock()
+	defer muxes.Unlock()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.refs--
+	if m.refs > 0 {
+		return
+	}
+	if m.key != "" {
+		delete(muxes.open, m.key)
+	}
+	m.ctrl.Close()
+}
+
+// selectChannel enables channel ch. It must be called with m.mu held.
+func (m *Mux) selectChannel(ch int) error {
+	if m.current == ch {
+		return nil
+	}
+
+	// The TCA9548A has a single control register and keeps the last byte
+	// written to it, so the register address is the selection itself.
+	sel := byte(1 << ch)
+	if err := m.ctrl.Write(sel, sel); err != nil {
+		m.current = -1
+		return fmt.Errorf("max30102: could not select multiplexer channel %d: %w", ch, err)
+	}
+	m.current = ch
+
+	return nil
+}
+
+type muxBus struct {
+	mux *Mux
+	ch  int
+	bus Bus
+}
+
+func (b *muxBus) Read(reg byte) (byte, error) {
+	b.mux.mu.Lock()
+	defer b.mux.mu.Unlock()
+
+	if err := b.mux.selectChannel(b.ch); err != nil {
+		return 0, err
+	}
+	return b.bus.Read(reg)
+}
+
+func (b *muxBus) ReadBytes(reg byte, n int) ([]byte, error) {
+	b.mux.mu.Lock()
+	defer b.mux.mu.Unlock()
+
+	if err := b.mux.selectChannel(b.ch); err != nil {
+		return nil, err
+	}
+	return b.bus.ReadBytes(reg, n)
+}
+
+func (b *muxBus) Write(reg, data byte) error {
+	b.mux.mu.Lock()
+	defer b.mux.mu.Unlock()
+
+	if err := b.mux.selectChannel(b.ch); err != nil {
+		return err
+	}
+	return b.bus.Write(reg, data)
+}
+
+func (b *muxBus) Close() {
+	b.bus.Close()
+	b.mux.Close()
+}
@@ -1,6 +1,15 @@
 package max30102
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidSetting throws an error when an option is given a value that
+	// is not one of its constants.
+	ErrInvalidSetting error = errors.New("max30102: invalid setting")
+)
 
 // Option defines a functional option for the device.
 type Option func(d *Device) (Option, error)
@@ -20,26 +29,15 @@ func (d *Device) Options(options ...Option) (Option, error) {
 	return old, nil
 }
 
-func (d *Device) config(reg, mask, flag byte) (byte, error) {
-	cfg, err := d.Read(reg)
-	if err != nil {
-		return 0, fmt.Errorf("could not get %v from %v: %w", mask, reg, err)
-	}
-	old := cfg &^ mask
-	cfg &= mask
-	flag = flag &^ mask
-	cfg |= flag
-	if err := d.Write(reg, cfg); err != nil {
-		return 0, fmt.Errorf("could not set %v in %v: %w", flag, reg, err)
-	}
-
-	return old, nil
-}
-
 // Mode sets the operation mode of the device.
 func Mode(mode byte) Option {
 	return func(d *Device) (Option, error) {
-		old, err := d.config(ModeCfg, modeMask, mode)
+		var r ModeConfig
+		var old byte
+		err := d.update(&r, func() {
+			old = r.Mode
+			r.Mode = mode
+		})
 		if err != nil {
 			return nil, fmt.Errorf("max30102: could not configure mode %#x: %w", mode, err)
 		}
@@ -53,6 +51,9 @@ func Mode(mode byte) Option {
 		if err = d.Write(FIFORdPtr, 0); err != nil {
 			return nil, fmt.Errorf("max30102: could not configure mode %#x: %w", mode, err)
 		}
+		if err = d.updateSlots(); err != nil {
+			return nil, fmt.Errorf("max30102: could not configure mode %#x: %w", mode, err)
+		}
 
 		return Mode(old), nil
 	}
@@ -62,15 +63,9 @@ func Mode(mode byte) Option {
 // from 0.0 to 51.0 mA and the value is rounded down to the nearest multiple of 0.2.
 func RedPulseAmp(current float64) Option {
 	return func(d *Device) (Option, error) {
-		if current > 51 {
-			current = 51
-		}
-		if current < 0 {
-			current = 0
-		}
-		b := byte(current * 5)
+		b := pulseAmp(current)
 
-		old, err := d.config(Led1PA, 0, b)
+		old, err := d.swap(Led1PA, b)
 		if err != nil {
 			return nil, fmt.Errorf("max30102: could not configure red LED pulse amplitud: %w", err)
 		}
@@ -83,15 +78,9 @@ func RedPulseAmp(current float64) Option {
 // from 0.0 to 51.0 mA and the value is rounded down to the nearest multiple of 0.2.
 func IRPulseAmp(current float64) Option {
 	return func(d *Device) (Option, error) {
-		if current > 51 {
-			current = 51
-		}
-		if current < 0 {
-			current = 0
-		}
-		b := byte(current * 5)
+		b := pulseAmp(current)
 
-		old, err := d.config(Led2PA, 0, b)
+		old, err := d.swap(Led2PA, b)
 		if err != nil {
 			return nil, fmt.Errorf("max30102: could not configure IR LED pulse amplitud: %w", err)
 		}
@@ -100,10 +89,19 @@ func IRPulseAmp(current float64) Option {
 	}
 }
 
-// PulseWidth sets the pulse width of the device.
+// PulseWidth sets the pulse width of the device (PW69 to PW411).
 func PulseWidth(pw byte) Option {
 	return func(d *Device) (Option, error) {
-		old, err := d.config(SpO2Cfg, pwMask, pw)
+		if pw&^pwBits != 0 {
+			return nil, fmt.Errorf("%w: pulse width %#x", ErrInvalidSetting, pw)
+		}
+
+		var r SpO2Config
+		var old byte
+		err := d.update(&r, func() {
+			old = r.PulseWidth
+			r.PulseWidth = pw
+		})
 		if err != nil {
 			return nil, fmt.Errorf("max30102: could not configure pulse width: %w", err)
 		}
@@ -112,10 +110,20 @@ func PulseWidth(pw byte) Option {
 	}
 }
 
-// SampleRate sets the SpO2 sample rate control of the device.
+// SampleRate sets the SpO2 sample rate control of the device (SR50 to
+// SR3200).
 func SampleRate(sr byte) Option {
 	return func(d *Device) (Option, error) {
-		old, err := d.config(SpO2Cfg, srMask, sr)
+		if sr&^srBits != 0 {
+			return nil, fmt.Errorf("%w: sample rate %#x", ErrInvalidSetting, sr)
+		}
+
+		var r SpO2Config
+		var old byte
+		err := d.update(&r, func() {
+			old = r.SampleRate
+			r.SampleRate = sr
+		})
 		if err != nil {
 			return nil, fmt.Errorf("max30102: could not configure sample rate: %w", err)
 		}
@@ -124,10 +132,28 @@ func SampleRate(sr byte) Option {
 	}
 }
 
-// InterruptEnable enables interrupts.
+// InterruptEnable enables the interrupts set in i (AlmostFull, NewFIFOData,
+// AmbientLightCancelOvf and DieTempReady) and disables the others.
 func InterruptEnable(i byte) Option {
 	return func(d *Device) (Option, error) {
-		old, err := d.config(IntEna1, ^i, i)
+		if i&^(intEna1Bits|intEna2Bits) != 0 {
+			return nil, fmt.Errorf("%w: interrupt flags %#x", ErrInvalidSetting, i)
+		}
+
+		var r1 IntEnable1
+		var old byte
+		err := d.update(&r1, func() {
+			old = r1.Encode()
+			r1.Decode(i)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("max30102: could not configure interrupt flags: %w", err)
+		}
+		var r2 IntEnable2
+		err = d.update(&r2, func() {
+			old |= r2.Encode()
+			r2.Decode(i)
+		})
 		if err != nil {
 			return nil, fmt.Errorf("max30102: could not configure interrupt flags: %w", err)
 		}
@@ -136,12 +162,80 @@ func InterruptEnable(i byte) Option {
 	}
 }
 
+// SampleAveraging sets the number of samples averaged by the device for each
+// FIFO sample (Avg1 to Avg32). Averaging divides the rate of the FIFO by the
+// same number.
+func SampleAveraging(avg byte) Option {
+	return func(d *Device) (Option, error) {
+		if avg&^avgBits != 0 || avg > Avg32 {
+			return nil, fmt.Errorf("%w: sample averaging %#x", ErrInvalidSetting, avg)
+		}
+
+		var r FIFOConfig
+		var old byte
+		err := d.update(&r, func() {
+			old = r.Averaging
+			r.Averaging = avg
+		})
+		if err != nil {
+			return nil, fmt.Errorf("max30102: could not configure sample averaging: %w", err)
+		}
+
+		return SampleAveraging(old), nil
+	}
+}
+
+// FIFORollover sets whether the FIFO overwrites its oldest samples when it is
+// full. Otherwise, new samples are lost until the FIFO is read.
+func FIFORollover(on bool) Option {
+	return func(d *Device) (Option, error) {
+		var r FIFOConfig
+		var old bool
+		err := d.update(&r, func() {
+			old = r.Rollover
+			r.Rollover = on
+		})
+		if err != nil {
+			return nil, fmt.Errorf("max30102: could not configure FIFO rollover: %w", err)
+		}
+
+		return FIFORollover(old), nil
+	}
+}
+
+// ADCRange sets the full scale of the ADC (ADC2048 to ADC16384, in nA).
+// Higher ranges avoid saturating with strong signals.
+func ADCRange(r byte) Option {
+	return func(d *Device) (Option, error) {
+		if r&^adcBits != 0 {
+			return nil, fmt.Errorf("%w: ADC range %#x", ErrInvalidSetting, r)
+		}
+
+		var c SpO2Config
+		var old byte
+		err := d.update(&c, func() {
+			old = c.ADCRange
+			c.ADCRange = r
+		})
+		if err != nil {
+			return nil, fmt.Errorf("max30102: could not configure ADC range: %w", err)
+		}
+
+		return ADCRange(old), nil
+	}
+}
+
 // AlmostFullValue sets when the AlmostFull interrupt should be triggered. It
 // can take values from 0 to 15.
 func AlmostFullValue(left byte) Option {
 	return func(d *Device) (Option, error) {
-		left &= ^fifoFullMask
-		old, err := d.config(FIFOCfg, fifoFullMask, left)
+		left &= fifoFullBits
+		var r FIFOConfig
+		var old byte
+		err := d.update(&r, func() {
+			old = r.AlmostFull
+			r.AlmostFull = left
+		})
 		if err != nil {
 			return nil, fmt.Errorf("max30102: could not configure almost full value to %d: %w", left, err)
 		}
@@ -149,3 +243,50 @@ func AlmostFullValue(left byte) Option {
 		return AlmostFullValue(old), nil
 	}
 }
+
+// GreenPulseAmp sets the pulse amplitude of the green LED (MAX30101 and
+// MAX30105 only). It accepts values from 0.0 to 51.0 mA and the value is
+// rounded down to the nearest multiple of 0.2.
+func GreenPulseAmp(current float64) Option {
+	return func(d *Device) (Option, error) {
+		b := pulseAmp(current)
+
+		old, err := d.swap(Led3PA, b)
+		if err != nil {
+			return nil, fmt.Errorf("max30102: could not configure green LED pulse amplitud: %w", err)
+		}
+
+		return GreenPulseAmp(float64(old) / 5), nil
+	}
+}
+
+// LEDSlots sets which LED drives each time slot in multi-LED mode
+// (ModeMultiLed). It takes up to 4 slots (SlotRed, SlotIR, SlotGreen or
+// SlotNone), starting from slot 1. Missing slots are disabled. The device
+// samples the slots in order until the first disabled slot.
+func LEDSlots(slots ...byte) Option {
+	return func(d *Device) (Option, error) {
+		if len(slots) > maxSlots {
+			return nil, fmt.Errorf("max30102: could not configure %d LED slots, maximum is %d", len(slots), maxSlots)
+		}
+		s := make([]byte, maxSlots)
+		copy(s, slots)
+
+		old := make([]byte, maxSlots)
+		for i, reg := range []byte{MultiLedModeS2S1, MultiLedModeS4S3} {
+			cfg := (s[2*i+1]&slotMask)<<4 | s[2*i]&slotMask
+			prev, err := d.swap(reg, cfg)
+			if err != nil {
+				return nil, fmt.Errorf("max30102: could not configure LED slots: %w", err)
+			}
+			old[2*i] = prev & slotMask
+			old[2*i+1] = prev >> 4 & slotMask
+		}
+
+		if err := d.updateSlots(); err != nil {
+			return nil, fmt.Errorf("max30102: could not configure LED slots: %w", err)
+		}
+
+		return LEDSlots(old...), nil
+	}
+}
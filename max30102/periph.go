@@ -0,0 +1,83 @@
+//go:build !tinygo
+// +build !tinygo
+
+package max30102
+
+import (
+	"fmt"
+
+	"periph.io/x/periph/conn"
+	"periph.io/x/periph/conn/i2c"
+)
+
+// connBus adapts a periph.io connection to a Bus.
+type connBus struct {
+	c conn.Conn
+}
+
+// ConnBus returns a bus that communicates through an existing periph.io
+// connection, such as an *i2c.Dev. Closing the bus does not close the
+// connection, which is still owned by the caller.
+func ConnBus(c conn.Conn) Bus {
+	return &connBus{
+		c: c,
+	}
+}
+
+// NewWithConn returns a new MAX30102 device that communicates through an
+// existing periph.io connection. The device is initialized with the same
+// defaults as New. Closing the device does not close the connection.
+func NewWithConn(c conn.Conn) (*Device, error) {
+	return NewWithBus(ConnBus(c))
+}
+
+// NewWithI2C returns a new MAX30102 device at addr on an existing periph.io
+// I²C bus, so that the bus can be shared with other periph.io drivers. If
+// "addr" is 0, the default address (0x57) is used. Closing the device does not
+// close the bus.
+func NewWithI2C(b i2c.Bus, addr uint16) (*Device, error) {
+	if addr == 0 {
+		addr = Addr
+	}
+
+	return NewWithConn(&i2c.Dev{
+		Addr: addr,
+		Bus:  b,
+	})
+}
+
+func (b *connBus) Read(reg byte) (byte, error) {
+	r := make([]byte, 1)
+	if err := b.c.Tx([]byte{reg}, r); err != nil {
+		return 0, fmt.Errorf("max30102: could not read register %#x on %s: %w", reg, b.c, err)
+	}
+
+	return r[0], nil
+}
+
+func (b *connBus) ReadBytes(reg byte, n int) ([]byte, error) {
+	r := make([]byte, n)
+	if err := b.ReadInto(reg, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (b *connBus) ReadInto(reg byte, r []byte) error {
+	if err := b.c.Tx([]byte{reg}, r); err != nil {
+		return fmt.Errorf("max30102: could not read %d bytes from register %#x on %s: %w", len(r), reg, b.c, err)
+	}
+
+	return nil
+}
+
+func (b *connBus) Write(reg, data byte) error {
+	if err := b.c.Tx([]byte{reg, data}, nil); err != nil {
+		return fmt.Errorf("max30102: could not write %#x to register %#x on %s: %w", data, reg, b.c, err)
+	}
+
+	return nil
+}
+
+func (b *connBus) Close() {}
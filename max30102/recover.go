@@ -0,0 +1,90 @@
+package max30102
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrDeviceReset throws an error when the device lost its configuration,
+// usually after a brownout. Use Reinit to configure the device again.
+var ErrDeviceReset error = errors.New("max30102: device was reset")
+
+// Check checks that the mode register of the device still holds the last
+// value written to it, and returns ErrDeviceReset if it does not.
+func (d *Device) Check() error {
+	want, ok := d.shadow[ModeCfg]
+	if !ok {
+		return nil
+	}
+	mode, err := d.Read(ModeCfg)
+	if err != nil {
+		return fmt.Errorf("max30102: could not check mode: %w", err)
+	}
+	if mode != want {
+		return fmt.Errorf("%w: mode is %#x instead of %#x", ErrDeviceReset, mode, want)
+	}
+
+	return nil
+}
+
+// Reinit resets the device and writes back all the configuration registers
+// set through options since the device was created or last reset. If it
+// fails, the configuration is kept, so that Reinit can be retried.
+func (d *Device) Reinit() error {
+	shadow := d.shadow
+	if err := d.reinit(shadow); err != nil {
+		// Reset cleared the configuration, and only part of it may have
+		// been written back.
+		d.shadow = shadow
+		return fmt.Errorf("max30102: could not reinitialize device: %w", err)
+	}
+
+	return nil
+}
+
+func (d *Device) reinit(shadow map[byte]byte) error {
+	if err := d.clearPowerReady(); err != nil {
+		return err
+	}
+	if err := d.Reset(); err != nil {
+		return err
+	}
+
+	for reg := 0; reg < 0x100; reg++ {
+		cfg, ok := shadow[byte(reg)]
+		if !ok {
+			continue
+		}
+		if err := d.writeConfig(byte(reg), cfg); err != nil {
+			return err
+		}
+	}
+
+	return d.restartFIFO()
+}
+
+// restartFIFO empties the FIFO after the configuration of the device changed
+// and updates the LED slots.
+func (d *Device) restartFIFO() error {
+	for _, reg := range []byte{FIFOWrPtr, OvfCount, FIFORdPtr} {
+		if err := d.Write(reg, 0); err != nil {
+			return fmt.Errorf("could not empty FIFO: %w", err)
+		}
+	}
+	if err := d.updateSlots(); err != nil {
+		return err
+	}
+	// Samples taken while configuring the device were discarded, so clear
+	// the flags they raised.
+	return d.clearPowerReady()
+}
+
+// clearPowerReady clears the PowerReady flag raised when the device powers
+// on, so that it is only seen again after a brownout. All the other flags of
+// IntStat1 are cleared too.
+func (d *Device) clearPowerReady() error {
+	if _, err := d.Read(IntStat1); err != nil {
+		return fmt.Errorf("could not clear interrupt status: %w", err)
+	}
+	return nil
+}
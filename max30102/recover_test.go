@@ -0,0 +1,64 @@
+package max30102_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cgxeiji/max3010x/emulator"
+	"github.com/cgxeiji/max3010x/fault"
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+func TestReinitRetry(t *testing.T) {
+	e := emulator.New(emulator.Constant(0.25, 0.5))
+	bus := fault.Wrap(e)
+	d, err := max30102.NewWithBus(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if _, err := d.Options(
+		max30102.RedPulseAmp(5),
+		max30102.SampleRate(max30102.SR200),
+	); err != nil {
+		t.Fatal(err)
+	}
+
+	regs := []byte{
+		max30102.IntEna1, max30102.FIFOCfg, max30102.ModeCfg,
+		max30102.SpO2Cfg, max30102.Led1PA, max30102.Led2PA,
+	}
+	want := make(map[byte]byte)
+	for _, reg := range regs {
+		if want[reg], err = e.Read(reg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	e.PowerCycle()
+	// IntEna1 is written back first, then FIFOCfg fails.
+	undo := bus.Faults(fault.NACK(max30102.FIFOCfg))
+	if err := d.Reinit(); !errors.Is(err, fault.ErrNACK) {
+		t.Fatalf("Reinit() with NACK = %v, want ErrNACK", err)
+	}
+	if err := d.Check(); !errors.Is(err, max30102.ErrDeviceReset) {
+		t.Errorf("Check() after failed Reinit = %v, want ErrDeviceReset", err)
+	}
+
+	bus.Faults(undo)
+	if err := d.Reinit(); err != nil {
+		t.Fatalf("Reinit() retry = %v", err)
+	}
+	for _, reg := range regs {
+		got, err := e.Read(reg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want[reg] {
+			t.Errorf("register %#x = %#x after retry, want %#x", reg, got, want[reg])
+		}
+	}
+	if err := d.Check(); err != nil {
+		t.Errorf("Check() after retry = %v, want nil", err)
+	}
+}
@@ -0,0 +1,312 @@
+package max30102
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// ErrVerify throws an error when a configuration register does not hold the
+// value written to it (see Verify).
+var ErrVerify error = errors.New("max30102: register verification failed")
+
+// Register defines a typed configuration register. Multi-bit fields hold the
+// value of the constants of the field already shifted in place (e.g. SR100 or
+// PW411), so that they can be compared with them directly.
+type Register interface {
+	// Addr returns the address of the register.
+	Addr() byte
+	// Decode sets the fields from the value of the register.
+	Decode(b byte)
+	// Encode returns the value of the register.
+	Encode() byte
+	String() string
+}
+
+// Register fields
+const (
+	modeBits     byte = 0b0000_0111
+	modeSHDN     byte = (1 << 7)
+	modeReset    byte = ResetControl
+	srBits       byte = 0b0001_1100
+	pwBits       byte = 0b0000_0011
+	adcBits      byte = 0b0110_0000
+	avgBits      byte = 0b1110_0000
+	rolloverBit  byte = (1 << 4)
+	fifoFullBits byte = 0b0000_1111
+	intEna1Bits       = AlmostFull | NewFIFOData | AmbientLightCancelOvf
+	intEna2Bits       = DieTempReady
+)
+
+var (
+	pulseWidths = [...]int{69, 118, 215, 411}
+	adcRanges   = [...]int{2048, 4096, 8192, 16384}
+)
+
+// ModeConfig defines the mode configuration register (ModeCfg).
+type ModeConfig struct {
+	Shutdown bool
+	Reset    bool
+	// Mode is ModeHR, ModeSpO2 or ModeMultiLed.
+	Mode byte
+}
+
+// Addr returns ModeCfg.
+func (r *ModeConfig) Addr() byte { return ModeCfg }
+
+// Decode sets the fields from b.
+func (r *ModeConfig) Decode(b byte) {
+	r.Shutdown = b&modeSHDN != 0
+	r.Reset = b&modeReset != 0
+	r.Mode = b & modeBits
+}
+
+// Encode returns the value of the register.
+func (r *ModeConfig) Encode() byte {
+	b := r.Mode & modeBits
+	if r.Shutdown {
+		b |= modeSHDN
+	}
+	if r.Reset {
+		b |= modeReset
+	}
+	return b
+}
+
+func (r *ModeConfig) String() string {
+	s := "mode=" + modeName(r.Mode)
+	if r.Shutdown {
+		s += " shutdown"
+	}
+	if r.Reset {
+		s += " reset"
+	}
+	return s
+}
+
+func modeName(mode byte) string {
+	switch mode {
+	case ModeHR:
+		return "HR"
+	case ModeSpO2:
+		return "SpO2"
+	case ModeMultiLed:
+		return "multi-LED"
+	}
+	return "unknown(" + strconv.Itoa(int(mode)) + ")"
+}
+
+// SpO2Config defines the SpO2 configuration register (SpO2Cfg).
+type SpO2Config struct {
+	// ADCRange is the full scale of the ADC, bits 6:5 of the register.
+	ADCRange byte
+	// SampleRate is SR50 to SR3200.
+	SampleRate byte
+	// PulseWidth is PW69 to PW411.
+	PulseWidth byte
+}
+
+// Addr returns SpO2Cfg.
+func (r *SpO2Config) Addr() byte { return SpO2Cfg }
+
+// Decode sets the fields from b.
+func (r *SpO2Config) Decode(b byte) {
+	r.ADCRange = b & adcBits
+	r.SampleRate = b & srBits
+	r.PulseWidth = b & pwBits
+}
+
+// Encode returns the value of the register.
+func (r *SpO2Config) Encode() byte {
+	return r.ADCRange&adcBits | r.SampleRate&srBits | r.PulseWidth&pwBits
+}
+
+func (r *SpO2Config) String() string {
+	return fmt.Sprintf("adc=%dnA rate=%d/s pulse=%dus",
+		adcRanges[(r.ADCRange&adcBits)>>5],
+		sampleRates[(r.SampleRate&srBits)>>2],
+		pulseWidths[r.PulseWidth&pwBits],
+	)
+}
+
+// FIFOConfig defines the FIFO configuration register (FIFOCfg).
+type FIFOConfig struct {
+	// Averaging is the sample averaging, bits 7:5 of the register.
+	Averaging byte
+	// Rollover lets the FIFO overwrite old samples when it is full.
+	Rollover bool
+	// AlmostFull is the number of empty samples left in the FIFO when the
+	// AlmostFull flag is raised (0 to 15).
+	AlmostFull byte
+}
+
+// Addr returns FIFOCfg.
+func (r *FIFOConfig) Addr() byte { return FIFOCfg }
+
+// Decode sets the fields from b.
+func (r *FIFOConfig) Decode(b byte) {
+	r.Averaging = b & avgBits
+	r.Rollover = b&rolloverBit != 0
+	r.AlmostFull = b & fifoFullBits
+}
+
+// Encode returns the value of the register.
+func (r *FIFOConfig) Encode() byte {
+	b := r.Averaging&avgBits | r.AlmostFull&fifoFullBits
+	if r.Rollover {
+		b |= rolloverBit
+	}
+	return b
+}
+
+func (r *FIFOConfig) String() string {
+	return fmt.Sprintf("average=%d rollover=%t almost-full=%d",
+		averaging(r.Averaging), r.Rollover, r.AlmostFull&fifoFullBits)
+}
+
+// averaging returns the number of samples averaged for the averaging field.
+func averaging(avg byte) int {
+	n := 1 << ((avg & avgBits) >> 5)
+	if n > 32 {
+		n = 32
+	}
+	return n
+}
+
+// IntEnable1 defines the interrupt enable register 1 (IntEna1).
+type IntEnable1 struct {
+	AlmostFull            bool
+	NewFIFOData           bool
+	AmbientLightCancelOvf bool
+}
+
+// Addr returns IntEna1.
+func (r *IntEnable1) Addr() byte { return IntEna1 }
+
+// Decode sets the fields from b.
+func (r *IntEnable1) Decode(b byte) {
+	r.AlmostFull = b&AlmostFull != 0
+	r.NewFIFOData = b&NewFIFOData != 0
+	r.AmbientLightCancelOvf = b&AmbientLightCancelOvf != 0
+}
+
+// Encode returns the value of the register.
+func (r *IntEnable1) Encode() byte {
+	var b byte
+	if r.AlmostFull {
+		b |= AlmostFull
+	}
+	if r.NewFIFOData {
+		b |= NewFIFOData
+	}
+	if r.AmbientLightCancelOvf {
+		b |= AmbientLightCancelOvf
+	}
+	return b
+}
+
+func (r *IntEnable1) String() string {
+	return fmt.Sprintf("almost-full=%t new-data=%t ambient-light-ovf=%t",
+		r.AlmostFull, r.NewFIFOData, r.AmbientLightCancelOvf)
+}
+
+// IntEnable2 defines the interrupt enable register 2 (IntEna2).
+type IntEnable2 struct {
+	DieTempReady bool
+}
+
+// Addr returns IntEna2.
+func (r *IntEnable2) Addr() byte { return IntEna2 }
+
+// Decode sets the fields from b.
+func (r *IntEnable2) Decode(b byte) {
+	r.DieTempReady = b&DieTempReady != 0
+}
+
+// Encode returns the value of the register.
+func (r *IntEnable2) Encode() byte {
+	if r.DieTempReady {
+		return DieTempReady
+	}
+	return 0
+}
+
+func (r *IntEnable2) String() string {
+	return fmt.Sprintf("die-temp-ready=%t", r.DieTempReady)
+}
+
+// ReadRegister reads the value of a typed register from the device.
+func (d *Device) ReadRegister(r Register) error {
+	b, err := d.Read(r.Addr())
+	if err != nil {
+		return fmt.Errorf("max30102: could not read register %#x: %w", r.Addr(), err)
+	}
+	r.Decode(b)
+
+	return nil
+}
+
+// WriteRegister writes a typed register to the device.
+func (d *Device) WriteRegister(r Register) error {
+	if err := d.writeConfig(r.Addr(), r.Encode()); err != nil {
+		return fmt.Errorf("max30102: could not write register %#x: %w", r.Addr(), err)
+	}
+
+	return nil
+}
+
+// update reads r from the device, calls f to modify it and writes it back.
+func (d *Device) update(r Register, f func()) error {
+	b, err := d.Read(r.Addr())
+	if err != nil {
+		return fmt.Errorf("could not get register %#x: %w", r.Addr(), err)
+	}
+	r.Decode(b)
+	f()
+
+	return d.writeConfig(r.Addr(), r.Encode())
+}
+
+// swap writes b to a configuration register and returns its previous value.
+func (d *Device) swap(reg, b byte) (byte, error) {
+	old, err := d.Read(reg)
+	if err != nil {
+		return 0, fmt.Errorf("could not get register %#x: %w", reg, err)
+	}
+
+	return old, d.writeConfig(reg, b)
+}
+
+// writeConfig writes a configuration register, verifying it if needed, and
+// remembers its value.
+func (d *Device) writeConfig(reg, b byte) error {
+	if err := d.Write(reg, b); err != nil {
+		return fmt.Errorf("could not set register %#x to %#x: %w", reg, b, err)
+	}
+	if d.verify {
+		got, err := d.Read(reg)
+		if err != nil {
+			return fmt.Errorf("could not verify register %#x: %w", reg, err)
+		}
+		if got != b {
+			return fmt.Errorf("%w: register %#x is %#x instead of %#x", ErrVerify, reg, got, b)
+		}
+	}
+
+	if d.shadow == nil {
+		d.shadow = make(map[byte]byte)
+	}
+	d.shadow[reg] = b
+
+	return nil
+}
+
+// Verify makes the device read back every configuration register after
+// writing it, and fail with ErrVerify if it does not hold the written value.
+func Verify(on bool) Option {
+	return func(d *Device) (Option, error) {
+		old := d.verify
+		d.verify = on
+		return Verify(old), nil
+	}
+}
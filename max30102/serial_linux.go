@@ -0,0 +1,145 @@
+//go:build linux && !tinygo
+// +build linux,!tinygo
+
+package max30102
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/cgxeiji/serial"
+	"periph.io/x/periph/conn/i2c/i2creg"
+	"periph.io/x/periph/host"
+)
+
+// New returns a new MAX30102 device. By default, this sets the LED pulse
+// amplitude to 2.4mA, with a pulse width of 411us and a sample rate of 100
+// samples/s.
+//
+// Argument "busName" can be used to specify the exact bus to use ("/dev/i2c-2", "I2C2", "2").
+// Argument "addr" can be used to specify alternative address if default (0x57) is unavailable and changed.
+// If "busName" argument is specified as an empty string "" the first available bus will be used.
+func New(busName string, addr uint16) (*Device, error) {
+	if addr == 0 {
+		addr = Addr
+	}
+
+	i2c, err := serial.NewI2C(busName, addr)
+	if err != nil {
+		return nil, fmt.Errorf("max30102: could not initialize I2C: %w", err)
+	}
+
+	d, err := NewWithBus(i2c)
+	if err != nil {
+		i2c.Close()
+		return nil, err
+	}
+
+	return d, nil
+}
+
+// OpenMux returns the multiplexer at addr on the I²C bus busName (see New).
+// Multiplexers opened more than once are shared, so that all their devices
+// are serialized, even if their bus is named differently ("", "/dev/i2c-1",
+// "I2C1" or "1"). If "addr" is 0, MuxAddr is used.
+func OpenMux(busName string, addr uint16) (*Mux, error) {
+	if addr == 0 {
+		addr = MuxAddr
+	}
+	bus, err := busPath(busName)
+	if err != nil {
+		return nil, fmt.Errorf("max30102: could not initialize multiplexer: %w", err)
+	}
+	key := fmt.Sprintf("%s@%#x", bus, addr)
+
+	muxes.Lock()
+	defer muxes.Unlock()
+
+	if m, ok := muxes.open[key]; ok {
+		m.mu.Lock()
+		m.refs++
+		m.mu.Unlock()
+		return m, nil
+	}
+
+	i2c, err := serial.NewI2C(bus, addr)
+	if err != nil {
+		return nil, fmt.Errorf("max30102: could not initialize multiplexer: %w", err)
+	}
+	m := NewMux(i2c)
+	m.key = key
+	muxes.open[key] = m
+
+	return m, nil
+}
+
+// busPath returns the name of the I²C bus that busName refers to, such as
+// "/dev/i2c-1", choosing the bus the same way as New. Unknown names are
+// returned as they are.
+func busPath(busName string) (string, error) {
+	if _, err := host.Init(); err != nil {
+		return "", fmt.Errorf("could not initialize host: %w", err)
+	}
+
+	// The default bus is the one with the lowest number, or the first one
+	// by name if they are not numbered.
+	var def *i2creg.Ref
+	for _, r := range i2creg.All() {
+		if busName == "" {
+			if def == nil || r.Number != -1 && (def.Number == -1 || r.Number < def.Number) {
+				def = r
+			}
+			continue
+		}
+
+		if r.Name == busName {
+			return r.Name, nil
+		}
+		for _, a := range r.Aliases {
+			if a == busName {
+				return r.Name, nil
+			}
+		}
+		if n, err := strconv.Atoi(busName); err == nil && n != -1 && n == r.Number {
+			return r.Name, nil
+		}
+	}
+	if def != nil {
+		return def.Name, nil
+	}
+
+	return busName, nil
+}
+
+// NewOnMux returns a new MAX30102 device connected to channel ch of the
+// multiplexer at muxAddr. Arguments "busName" and "addr" behave the same as
+// in New.
+func NewOnMux(busName string, addr, muxAddr uint16, ch int) (*Device, error) {
+	if addr == 0 {
+		addr = Addr
+	}
+
+	m, err := OpenMux(busName, muxAddr)
+	if err != nil {
+		return nil, err
+	}
+	defer m.Close()
+
+	i2c, err := serial.NewI2C(busName, addr)
+	if err != nil {
+		return nil, fmt.Errorf("max30102: could not initialize I2C: %w", err)
+	}
+	bus, err := m.Bus(ch, i2c)
+	if err != nil {
+		i2c.Close()
+		return nil, err
+	}
+
+	d, err := NewWithBus(bus)
+	if err != nil {
+		bus.Close()
+		return nil, err
+	}
+
+	return d, nil
+}
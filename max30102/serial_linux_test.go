@@ -0,0 +1,70 @@
+//go:build linux && !tinygo
+// +build linux,!tinygo
+
+package max30102_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cgxeiji/max3010x/max30102"
+	"periph.io/x/periph/conn/i2c"
+	"periph.io/x/periph/conn/i2c/i2creg"
+	"periph.io/x/periph/conn/physic"
+	"periph.io/x/periph/host"
+)
+
+// fakeBus is an I²C bus that accepts every transaction.
+type fakeBus string
+
+func (b fakeBus) String() string                    { return string(b) }
+func (b fakeBus) Tx(addr uint16, w, r []byte) error { return nil }
+func (b fakeBus) SetSpeed(f physic.Frequency) error { return nil }
+func (b fakeBus) Close() error                      { return nil }
+
+func TestOpenMuxBusName(t *testing.T) {
+	if _, err := host.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if len(i2creg.All()) != 0 {
+		t.Skip("the host has I2C buses")
+	}
+
+	// The default bus is the one with the lowest number.
+	for _, n := range []int{7, 3} {
+		name := fmt.Sprintf("/dev/i2c-%d", n)
+		open := func() (i2c.BusCloser, error) {
+			return fakeBus(name), nil
+		}
+		if err := i2creg.Register(name, []string{fmt.Sprintf("I2C%d", n)}, n, open); err != nil {
+			t.Fatal(err)
+		}
+		defer i2creg.Unregister(name)
+	}
+
+	want, err := max30102.OpenMux("/dev/i2c-3", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer want.Close()
+
+	for _, name := range []string{"", "I2C3", "3"} {
+		m, err := max30102.OpenMux(name, max30102.MuxAddr)
+		if err != nil {
+			t.Fatalf("OpenMux(%q) = %v", name, err)
+		}
+		defer m.Close()
+		if m != want {
+			t.Errorf("OpenMux(%q) returned a different multiplexer than OpenMux(\"/dev/i2c-3\")", name)
+		}
+	}
+
+	other, err := max30102.OpenMux("/dev/i2c-7", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if other == want {
+		t.Error("OpenMux(\"/dev/i2c-7\") returned the multiplexer of /dev/i2c-3")
+	}
+}
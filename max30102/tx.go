@@ -0,0 +1,100 @@
+package max30102
+
+// I2C defines a minimal I²C bus, compatible with the I2C interface of
+// tinygo.org/x/drivers and with periph.io's i2c.Bus.
+type I2C interface {
+	// Tx writes w and then reads len(r) bytes from the device at addr.
+	Tx(addr uint16, w, r []byte) error
+}
+
+// ReaderInto is implemented by buses that can read into a buffer provided by
+// the caller. The device uses it, when available, to read the FIFO without
+// allocating memory.
+type ReaderInto interface {
+	// ReadInto reads len(b) bytes starting from a register into b.
+	ReadInto(reg byte, b []byte) error
+}
+
+// txBus adapts an I2C to a Bus.
+type txBus struct {
+	i2c  I2C
+	addr uint16
+	w    [2]byte
+	r    [1]byte
+}
+
+// TxBus returns a bus that talks to the device at addr through i2c. If "addr"
+// is 0, the default address (0x57) is used. Closing the bus does nothing, as
+// i2c is owned by the caller.
+func TxBus(i2c I2C, addr uint16) Bus {
+	if addr == 0 {
+		addr = Addr
+	}
+
+	return &txBus{
+		i2c:  i2c,
+		addr: addr,
+	}
+}
+
+// NewWithTx returns a new MAX30102 device at addr on a minimal I²C bus, such
+// as the machine.I2C of TinyGo. If "addr" is 0, the default address (0x57) is
+// used. The device is initialized with the same defaults as NewWithBus.
+func NewWithTx(i2c I2C, addr uint16) (*Device, error) {
+	return NewWithBus(TxBus(i2c, addr))
+}
+
+func (b *txBus) Read(reg byte) (byte, error) {
+	b.w[0] = reg
+	if err := b.i2c.Tx(b.addr, b.w[:1], b.r[:]); err != nil {
+		return 0, err
+	}
+
+	return b.r[0], nil
+}
+
+func (b *txBus) ReadBytes(reg byte, n int) ([]byte, error) {
+	r := make([]byte, n)
+	if err := b.ReadInto(reg, r); err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+func (b *txBus) ReadInto(reg byte, r []byte) error {
+	b.w[0] = reg
+	return b.i2c.Tx(b.addr, b.w[:1], r)
+}
+
+func (b *txBus) Write(reg, data byte) error {
+	b.w[0] = reg
+	b.w[1] = data
+	return b.i2c.Tx(b.addr, b.w[:2], nil)
+}
+
+func (b *txBus) Close() {}
+
+// readFIFO reads one sample from the FIFO. The returned slice is reused by
+// the next call.
+func (d *Device) readFIFO() ([]byte, error) {
+	return d.readFIFOBytes(d.sampleSize())
+}
+
+// readFIFOBytes reads n bytes from the FIFO in a single transaction. The
+// returned slice is reused by the next call.
+func (d *Device) readFIFOBytes(n int) ([]byte, error) {
+	if cap(d.buf) < n {
+		d.buf = make([]byte, n)
+	}
+	b := d.buf[:n]
+
+	if r, ok := d.bus.(ReaderInto); ok {
+		if err := r.ReadInto(FIFOData, b); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+
+	return d.bus.ReadBytes(FIFOData, n)
+}
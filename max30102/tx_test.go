@@ -0,0 +1,130 @@
+package max30102_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cgxeiji/max3010x/emulator"
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+// fakeTx is an I2C that forwards the transactions for addr to an emulator
+// and records them.
+type fakeTx struct {
+	addr uint16
+	e    *emulator.Emulator
+	err  error
+
+	txs   int
+	addrs map[uint16]int
+}
+
+func (f *fakeTx) Tx(addr uint16, w, r []byte) error {
+	f.txs++
+	if f.addrs == nil {
+		f.addrs = make(map[uint16]int)
+	}
+	f.addrs[addr]++
+	if f.err != nil {
+		return f.err
+	}
+	if addr != f.addr {
+		return errors.New("fakeTx: NACK")
+	}
+
+	switch {
+	case len(w) == 2 && len(r) == 0:
+		return f.e.Write(w[0], w[1])
+	case len(w) == 1 && len(r) > 0:
+		b, err := f.e.ReadBytes(w[0], len(r))
+		copy(r, b)
+		return err
+	}
+	return errors.New("fakeTx: unexpected transaction")
+}
+
+func TestNewWithTx(t *testing.T) {
+	tests := []struct {
+		name    string
+		devAddr uint16
+		addr    uint16
+		err     error
+		wantErr bool
+	}{
+		{name: "default address", devAddr: max30102.Addr, addr: 0},
+		{name: "explicit address", devAddr: 0x58, addr: 0x58},
+		{name: "wrong address", devAddr: 0x58, addr: 0, wantErr: true},
+		{name: "bus error", devAddr: max30102.Addr, err: errors.New("bus error"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &fakeTx{
+				addr: tt.devAddr,
+				e:    emulator.New(emulator.Pulse(72, 97)),
+				err:  tt.err,
+			}
+			d, err := max30102.NewWithTx(tx, tt.addr)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("NewWithTx() = nil error, want error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewWithTx() = %v", err)
+			}
+			defer d.Close()
+
+			want := tt.addr
+			if want == 0 {
+				want = max30102.Addr
+			}
+			if len(tx.addrs) != 1 || tx.addrs[want] == 0 {
+				t.Errorf("transactions by address = %v, want all to %#x", tx.addrs, want)
+			}
+
+			ir, red, err := d.IRRed()
+			if err != nil {
+				t.Fatalf("IRRed() = %v", err)
+			}
+			if ir <= 0 || red <= 0 {
+				t.Errorf("IRRed() = %v, %v, want positive values", ir, red)
+			}
+		})
+	}
+}
+
+func TestTxBusFIFOBurst(t *testing.T) {
+	tx := &fakeTx{
+		addr: max30102.Addr,
+		e:    emulator.New(emulator.Pulse(72, 97)),
+	}
+	d, err := max30102.NewWithTx(tx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	b, err := d.ReadFIFOContext(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.Len() == 0 {
+		t.Fatal("ReadFIFOContext() returned no samples")
+	}
+
+	// A burst reads the FIFO pointers and then all the samples, whatever
+	// their number.
+	tx.txs = 0
+	if _, err := d.ReadFIFO(); err != nil {
+		t.Fatal(err)
+	}
+	if tx.txs > 3 {
+		t.Errorf("ReadFIFO() used %d transactions, want at most 3", tx.txs)
+	}
+}
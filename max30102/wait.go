@@ -0,0 +1,210 @@
+package max30102
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+)
+
+var (
+	// ErrTimeout throws an error when a flag of the device is not set before
+	// the deadline. It is wrapped in a *WaitError, and also matches
+	// context.DeadlineExceeded.
+	ErrTimeout error = errors.New("max30102: timed out")
+
+	errInvalidBit = errors.New("invalid bit, it should be 1 or 0")
+)
+
+// Default deadlines of the functions without a context.
+const (
+	// resetTimeout and tempTimeout are well above the reset time and the
+	// temperature conversion time (29ms).
+	resetTimeout = 100 * time.Millisecond
+	tempTimeout  = 100 * time.Millisecond
+	// sampleMargin is added to the time needed to take the samples waited
+	// for, to allow for slow buses.
+	sampleMargin = 100 * time.Millisecond
+	// maxPoll is the longest time between two reads of a flag while polling.
+	maxPoll = 5 * time.Millisecond
+)
+
+// sampleRates are the sample rates in samples/s, indexed by SR50 to SR3200.
+var sampleRates = [...]int{50, 100, 200, 400, 800, 1000, 1600, 3200}
+
+// WaitError is returned when waiting for a flag of the device fails. It is
+// formatted only when needed, to keep the sampling path free of fmt.
+type WaitError struct {
+	// Reg is the register waited on.
+	Reg byte
+	// Flag is the flag waited on, to be set to Bit.
+	Flag byte
+	Bit  byte
+	Err  error
+}
+
+func (e *WaitError) Error() string {
+	s := "could not wait for " + strconv.Itoa(int(e.Flag)) +
+		" in " + strconv.Itoa(int(e.Reg)) +
+		" to be " + strconv.Itoa(int(e.Bit))
+	if e.Err != nil {
+		s += ": " + e.Err.Error()
+	}
+	return s
+}
+
+func (e *WaitError) Unwrap() error {
+	return e.Err
+}
+
+// waitUntil waits until flag in reg is set to bit, or until ctx is done.
+// While waiting on IntStat1, it returns ErrDeviceReset if the PowerReady flag
+// is raised.
+func (d *Device) waitUntil(ctx context.Context, reg, flag byte, bit byte) error {
+	if bit > 1 {
+		return errInvalidBit
+	}
+	if d.irq != nil && bit == 1 && (reg == IntStat1 || reg == IntStat2) {
+		return d.waitInterrupt(ctx, reg, flag)
+	}
+
+	var poll *time.Timer
+	for {
+		state, err := d.Read(reg)
+		if err != nil {
+			return &WaitError{Reg: reg, Flag: flag, Bit: bit, Err: err}
+		}
+		if reg == IntStat1 && state&PowerReady != 0 {
+			return &WaitError{Reg: reg, Flag: flag, Bit: bit, Err: ErrDeviceReset}
+		}
+		if (state&flag != 0) == (bit == 1) {
+			return nil
+		}
+
+		if poll == nil {
+			poll = time.NewTimer(d.pollInterval())
+			defer poll.Stop()
+		} else {
+			poll.Reset(d.pollInterval())
+		}
+		select {
+		case <-ctx.Done():
+			return &WaitError{Reg: reg, Flag: flag, Bit: bit, Err: ctxErr(ctx)}
+		case <-poll.C:
+		}
+	}
+}
+
+// pollInterval returns the time between two reads of a flag while polling: a
+// fraction of the sample period, so that samples are read soon after they are
+// taken without flooding the bus.
+func (d *Device) pollInterval() time.Duration {
+	p := d.SamplePeriod() / 4
+	if p > maxPoll {
+		p = maxPoll
+	}
+	return p
+}
+
+// waitInterrupt waits for an interrupt flag using the INT pin. The INT pin
+// stays asserted (and no new edge is generated) while any flag is set, so
+// both status registers are read, clearing their flags, before waiting.
+func (d *Device) waitInterrupt(ctx context.Context, reg, flag byte) error {
+	for {
+		status, err := d.ReadBytes(IntStat1, 2)
+		if err != nil {
+			return &WaitError{Reg: reg, Flag: flag, Bit: 1, Err: err}
+		}
+		if status[0]&PowerReady != 0 {
+			return &WaitError{Reg: reg, Flag: flag, Bit: 1, Err: ErrDeviceReset}
+		}
+		if status[reg-IntStat1]&flag != 0 {
+			return nil
+		}
+
+		if err := d.waitEdge(ctx); err != nil {
+			return &WaitError{Reg: reg, Flag: flag, Bit: 1, Err: err}
+		}
+	}
+}
+
+// waitEdge waits for the INT pin until ctx is done. As Interrupt.Wait cannot
+// be cancelled, a wait interrupted by ctx is kept pending and its edge is
+// used by the next call.
+func (d *Device) waitEdge(ctx context.Context) error {
+	if d.edge == nil {
+		edge := make(chan error, 1)
+		go func(irq Interrupt) {
+			edge <- irq.Wait()
+		}(d.irq)
+		d.edge = edge
+	}
+
+	select {
+	case err := <-d.edge:
+		d.edge = nil
+		return err
+	case <-ctx.Done():
+		return ctxErr(ctx)
+	}
+}
+
+// ctxErr returns an error matching both ErrTimeout and
+// context.DeadlineExceeded if the deadline of ctx was exceeded, or the error
+// of ctx otherwise.
+func ctxErr(ctx context.Context) error {
+	err := ctx.Err()
+	if errors.Is(err, context.DeadlineExceeded) {
+		return timeoutError{err}
+	}
+	return err
+}
+
+// timeoutError wraps the error of a context whose deadline was exceeded, so
+// that it matches ErrTimeout as well.
+type timeoutError struct {
+	err error
+}
+
+func (e timeoutError) Error() string {
+	return ErrTimeout.Error()
+}
+
+func (e timeoutError) Is(target error) bool {
+	return target == ErrTimeout
+}
+
+func (e timeoutError) Unwrap() error {
+	return e.err
+}
+
+// SamplePeriod returns the nominal time between two FIFO samples, based on
+// the sample rate and sample averaging configured.
+func (d *Device) SamplePeriod() time.Duration {
+	var spo2 SpO2Config
+	spo2.Decode(d.shadow[SpO2Cfg])
+	var fifo FIFOConfig
+	fifo.Decode(d.shadow[FIFOCfg])
+
+	rate := sampleRates[spo2.SampleRate>>2]
+	return time.Duration(averaging(fifo.Averaging)) * time.Second / time.Duration(rate)
+}
+
+// sampleTimeout returns a context with the default deadline to wait for n
+// FIFO samples.
+func (d *Device) sampleTimeout(n int) (context.Context, context.CancelFunc) {
+	// Wait for one more sample, as the current one could have just been
+	// missed.
+	timeout := time.Duration(n+1)*d.SamplePeriod() + sampleMargin
+
+	return context.WithTimeout(context.Background(), timeout)
+}
+
+// batchSize returns the number of samples in the FIFO when the AlmostFull
+// flag is raised.
+func (d *Device) batchSize() int {
+	var fifo FIFOConfig
+	fifo.Decode(d.shadow[FIFOCfg])
+
+	return fifoDepth - int(fifo.AlmostFull)
+}
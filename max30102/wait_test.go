@@ -0,0 +1,79 @@
+package max30102_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cgxeiji/max3010x/emulator"
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+func TestPollingTransactions(t *testing.T) {
+	bus := &countingBus{Bus: emulator.New(emulator.Pulse(72, 97))}
+	d, err := max30102.NewWithBus(bus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	if _, _, err := d.IRRed(); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := d.IRRedBatch(); err != nil {
+		t.Fatal(err)
+	}
+
+	// IRRedBatch waits for 32 samples (320ms at 100 samples/s). Polling
+	// every few milliseconds takes a few hundred transactions.
+	if bus.txs > 1000 {
+		t.Errorf("init, IRRed and IRRedBatch made %d transactions, want at most 1000", bus.txs)
+	}
+}
+
+func TestWaitDeadline(t *testing.T) {
+	d, err := max30102.NewWithBus(emulator.New(emulator.Constant(0.5, 0.5)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	// Nothing is sampled while shut down.
+	if err := d.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		f    func(ctx context.Context) error
+	}{
+		{"IRRedContext", func(ctx context.Context) error {
+			_, _, err := d.IRRedContext(ctx)
+			return err
+		}},
+		{"ReadFIFOContext", func(ctx context.Context) error {
+			_, err := d.ReadFIFOContext(ctx)
+			return err
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+
+			err := tt.f(ctx)
+			if !errors.Is(err, max30102.ErrTimeout) {
+				t.Errorf("err = %v, want ErrTimeout", err)
+			}
+			if !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("err = %v, want context.DeadlineExceeded", err)
+			}
+			var werr *max30102.WaitError
+			if !errors.As(err, &werr) {
+				t.Errorf("err = %v, want a *WaitError", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package max30105
+
+import (
+	"fmt"
+	"time"
+)
+
+// Reading defines the particle levels measured by a Detector.
+type Reading struct {
+	// Levels is the relative change of each sampled LED (in the order of
+	// Device.Slots) with respect to its baseline. Particles in front of the
+	// sensor reflect more light, so positive values mean more particles.
+	Levels []float64
+	// Level is the highest value of Levels.
+	Level float64
+	// Alarm is true while Level is above the threshold of the detector.
+	Alarm bool
+	// Time is the time when the reading was taken.
+	Time time.Time
+}
+
+// Event is emitted by a Detector when the alarm state changes.
+type Event struct {
+	// Alarm is true when the threshold was crossed upwards, and false when the
+	// level went back under half the threshold.
+	Alarm bool
+	// Level is the level that triggered the event.
+	Level float64
+	Time  time.Time
+}
+
+// Detector detects particles (e.g. smoke) by comparing the light reflected
+// into the sensor against a slowly moving baseline of clean air.
+type Detector struct {
+	dev *Device
+
+	baseline  []float64
+	rate      float64
+	threshold float64
+	alarm     bool
+
+	events chan Event
+}
+
+// DetectorOption defines a functional option for the detector.
+type DetectorOption func(det *Detector) DetectorOption
+
+// Threshold sets the level at which the alarm is raised. By default, the
+// threshold is 0.05 (5% more light than the baseline).
+func Threshold(level float64) DetectorOption {
+	return func(det *Detector) DetectorOption {
+		old := det.threshold
+		det.threshold = level
+		return Threshold(old)
+	}
+}
+
+// BaselineRate sets how fast the baseline follows the readings while the
+// alarm is not raised, from 0.0 (never) to 1.0 (immediately). By default, the
+// rate is 0.01 per batch.
+func BaselineRate(rate float64) DetectorOption {
+	return func(det *Detector) DetectorOption {
+		old := det.rate
+		det.rate = rate
+		return BaselineRate(old)
+	}
+}
+
+// NewDetector returns a new particle detector that reads from dev.
+func NewDetector(dev *Device, options ...DetectorOption) *Detector {
+	det := &Detector{
+		dev:       dev,
+		rate:      0.01,
+		threshold: 0.05,
+		events:    make(chan Event, 8),
+	}
+
+	for _, option := range options {
+		option(det)
+	}
+
+	return det
+}
+
+// Events returns a channel that receives an Event every time the alarm state
+// changes. Events are dropped if the channel is not being read.
+func (det *Detector) Events() <-chan Event {
+	return det.events
+}
+
+// Reset discards the baseline, which is measured again on the next Update.
+func (det *Detector) Reset() {
+	det.baseline = nil
+	det.alarm = false
+}
+
+// Update reads a batch of samples from the device and returns the current
+// particle levels. The first call after creating or resetting the detector
+// measures the baseline and returns zero levels.
+func (det *Detector) Update() (Reading, error) {
+	leds, err := det.dev.LEDsBatch()
+	if err != nil {
+		return Reading{}, fmt.Errorf("max30105: could not read particles: %w", err)
+	}
+
+	now := time.Now()
+	means := make([]float64, len(leds))
+	for ch, values := range leds {
+		means[ch] = mean(values)
+	}
+
+	if len(det.baseline) != len(means) {
+		det.baseline = means
+		det.alarm = false
+	}
+
+	r := Reading{
+		Levels: make([]float64, len(means)),
+		Time:   now,
+	}
+	for ch, m := range means {
+		if det.baseline[ch] == 0 {
+			continue
+		}
+		r.Levels[ch] = (m - det.baseline[ch]) / det.baseline[ch]
+		if ch == 0 || r.Levels[ch] > r.Level {
+			r.Level = r.Levels[ch]
+		}
+	}
+
+	switch {
+	case !det.alarm && r.Level >= det.threshold:
+		det.alarm = true
+		det.emit(Event{Alarm: true, Level: r.Level, Time: now})
+	case det.alarm && r.Level < det.threshold/2:
+		det.alarm = false
+		det.emit(Event{Alarm: false, Level: r.Level, Time: now})
+	}
+	r.Alarm = det.alarm
+
+	// Only follow the readings in clean air, so that slowly building smoke
+	// does not become the baseline.
+	if !det.alarm {
+		for ch, m := range means {
+			det.baseline[ch] += (m - det.baseline[ch]) * det.rate
+		}
+	}
+
+	return r, nil
+}
+
+func (det *Detector) emit(e Event) {
+	select {
+	case det.events <- e:
+	default:
+	}
+}
+
+func mean(a []float64) float64 {
+	if len(a) == 0 {
+		return 0
+	}
+
+	r := 0.0
+	for _, v := range a {
+		r += v
+	}
+
+	return r / float64(len(a))
+}
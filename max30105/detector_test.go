@@ -0,0 +1,208 @@
+package max30105_test
+
+import (
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cgxeiji/max3010x/emulator"
+	"github.com/cgxeiji/max3010x/max30102"
+	"github.com/cgxeiji/max3010x/max30105"
+)
+
+// air is a waveform of the light reflected by the air in front of the
+// sensor. The IR and green readings are scaled by the particles set.
+type air struct {
+	mu        sync.Mutex
+	ir, green float64
+}
+
+const clean = 0.2
+
+func (a *air) set(ir, green float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.ir, a.green = ir, green
+}
+
+func (a *air) Sample(time.Duration) (red, ir float64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return clean, clean * a.ir
+}
+
+func (a *air) Green(time.Duration) float64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return clean * a.green
+}
+
+// step is a batch of samples read by the detector.
+type step struct {
+	// ir and green are the light reflected relative to clean air.
+	ir, green float64
+	// reset resets the detector before reading.
+	reset bool
+
+	level float64
+	// levels are the levels of the red, IR and green LEDs, if checked.
+	levels []float64
+	alarm  bool
+	// event is the alarm state of the event emitted, if any.
+	event *bool
+}
+
+var (
+	raised  = func() *bool { b := true; return &b }()
+	cleared = func() *bool { b := false; return &b }()
+)
+
+func TestDetector(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []max30105.DetectorOption
+		steps   []step
+	}{
+		{
+			name: "clean air",
+			steps: []step{
+				{ir: 1, green: 1},
+				{ir: 1, green: 1},
+				{ir: 1, green: 1},
+			},
+		},
+		{
+			name: "smoke",
+			steps: []step{
+				{ir: 1, green: 1},
+				{ir: 1.1, green: 1.1, level: 0.1, alarm: true, event: raised},
+				// The baseline is kept while the alarm is raised.
+				{ir: 1.1, green: 1.1, level: 0.1, alarm: true},
+				{ir: 1, green: 1, event: cleared},
+			},
+		},
+		{
+			name: "less light",
+			steps: []step{
+				{ir: 1, green: 1},
+				// The level of the red LED is still the highest.
+				{ir: 0.8, green: 0.8, levels: []float64{0, -0.2, -0.2}},
+			},
+		},
+		{
+			name: "highest level",
+			steps: []step{
+				{ir: 1, green: 1},
+				{ir: 0.9, green: 1.2, level: 0.2, alarm: true, event: raised},
+			},
+		},
+		{
+			name: "hysteresis",
+			steps: []step{
+				{ir: 1, green: 1},
+				{ir: 1.06, green: 1, level: 0.06, alarm: true, event: raised},
+				// Above half the threshold.
+				{ir: 1.03, green: 1, level: 0.03, alarm: true},
+				{ir: 1.02, green: 1, level: 0.02, event: cleared},
+			},
+		},
+		{
+			name:    "threshold",
+			options: []max30105.DetectorOption{max30105.Threshold(0.2)},
+			steps: []step{
+				{ir: 1, green: 1},
+				{ir: 1.1, green: 1, level: 0.1},
+				{ir: 1.25, green: 1, level: 0.25, alarm: true, event: raised},
+			},
+		},
+		{
+			name: "slow drift",
+			steps: []step{
+				{ir: 1, green: 1},
+				{ir: 1.04, green: 1, level: 0.04},
+				// The baseline barely moved.
+				{ir: 1.08, green: 1, level: 0.08, alarm: true, event: raised},
+			},
+		},
+		{
+			name:    "slow drift followed",
+			options: []max30105.DetectorOption{max30105.BaselineRate(1)},
+			steps: []step{
+				{ir: 1, green: 1},
+				{ir: 1.04, green: 1, level: 0.04},
+				{ir: 1.08, green: 1, level: 0.04},
+				{ir: 1.12, green: 1, level: 0.04},
+			},
+		},
+		{
+			name: "reset",
+			steps: []step{
+				{ir: 1, green: 1},
+				{ir: 1.2, green: 1, level: 0.2, alarm: true, event: raised},
+				// The baseline is measured again, without an event.
+				{ir: 1.2, green: 1, reset: true},
+				{ir: 1.2, green: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := &air{ir: 1, green: 1}
+			d, err := max30105.NewWithBus(emulator.New(a))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+			// Read small batches quickly.
+			if _, err := d.Options(
+				max30102.SampleRate(max30102.SR800),
+				max30102.PulseWidth(max30102.PW69),
+				max30102.AlmostFullValue(24),
+			); err != nil {
+				t.Fatal(err)
+			}
+
+			det := max30105.NewDetector(d, tt.options...)
+			for i, s := range tt.steps {
+				a.set(s.ir, s.green)
+				if s.reset {
+					det.Reset()
+				}
+				r, err := det.Update()
+				if err != nil {
+					t.Fatalf("step %d: Update() = %v", i, err)
+				}
+
+				if math.Abs(r.Level-s.level) > 0.005 {
+					t.Errorf("step %d: Level = %.4f, want %.4f", i, r.Level, s.level)
+				}
+				for ch, want := range s.levels {
+					if math.Abs(r.Levels[ch]-want) > 0.005 {
+						t.Errorf("step %d: Levels[%d] = %.4f, want %.4f", i, ch, r.Levels[ch], want)
+					}
+				}
+				if r.Alarm != s.alarm {
+					t.Errorf("step %d: Alarm = %v, want %v", i, r.Alarm, s.alarm)
+				}
+
+				select {
+				case e := <-det.Events():
+					switch {
+					case s.event == nil:
+						t.Errorf("step %d: unexpected event %+v", i, e)
+					case e.Alarm != *s.event:
+						t.Errorf("step %d: event Alarm = %v, want %v", i, e.Alarm, *s.event)
+					case e.Level != r.Level:
+						t.Errorf("step %d: event Level = %v, want %v", i, e.Level, r.Level)
+					}
+				default:
+					if s.event != nil {
+						t.Errorf("step %d: no event, want Alarm = %v", i, *s.event)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,56 @@
+// Package max30105 implements the MAX30105 particle sensor. It shares the
+// register map of the MAX30102 with an additional green LED, so the device
+// reuses the max30102 driver and its options. On top of it, Detector tracks
+// the light reflected by airborne particles to detect smoke.
+package max30105
+
+import (
+	"fmt"
+
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+// Device constants. The MAX30105 reports the same part ID as the MAX30102.
+const (
+	Addr   = max30102.Addr
+	PartID = max30102.PartID
+)
+
+// Device defines a MAX30105 device. All the low level functions of the
+// max30102 driver are available.
+type Device struct {
+	*max30102.Device
+}
+
+// NewWithBus returns a new MAX30105 device that communicates through bus,
+// with the same defaults as New.
+func NewWithBus(bus max30102.Bus) (*Device, error) {
+	dev, err := max30102.NewWithBus(bus)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := setup(dev)
+	if err != nil {
+		dev.Close()
+		return nil, err
+	}
+
+	return d, nil
+}
+
+func setup(dev *max30102.Device) (*Device, error) {
+	if _, err := dev.Options(
+		max30102.RedPulseAmp(6.4),
+		max30102.IRPulseAmp(6.4),
+		max30102.GreenPulseAmp(6.4),
+		max30102.LEDSlots(max30102.SlotRed, max30102.SlotIR, max30102.SlotGreen),
+		max30102.Mode(max30102.ModeMultiLed),
+	); err != nil {
+		return nil, fmt.Errorf("max30105: could not initialize device: %w", err)
+	}
+
+	return &Device{
+		Device: dev,
+	}, nil
+}
@@ -0,0 +1,27 @@
+//go:build linux && !tinygo
+// +build linux,!tinygo
+
+package max30105
+
+import "github.com/cgxeiji/max3010x/max30102"
+
+// New returns a new MAX30105 device. On top of the max30102 defaults, this
+// sets the pulse amplitude of the red, IR and green LEDs to 6.4mA and the
+// device to multi-LED mode, sampling the red, IR and green LEDs in slots 1 to
+// 3.
+//
+// Arguments "busName" and "addr" behave the same as in max30102.New.
+func New(busName string, addr uint16) (*Device, error) {
+	dev, err := max30102.New(busName, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	d, err := setup(dev)
+	if err != nil {
+		dev.Close()
+		return nil, err
+	}
+
+	return d, nil
+}
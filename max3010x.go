@@ -3,7 +3,9 @@ package max3010x
 import (
 	"errors"
 	"fmt"
+	"sync"
 
+	"github.com/cgxeiji/max3010x/max30100"
 	"github.com/cgxeiji/max3010x/max30102"
 )
 
@@ -20,13 +22,16 @@ var (
 	// variation, therefore consistent measurements cannot be done (e.g.
 	// ambient light, moving finger, etc.).
 	ErrTooNoisy = errors.New("data has too much noise")
+	// ErrUnknownPart is thrown when the part ID read from the sensor does not
+	// match any supported MAX3010x device.
+	ErrUnknownPart = errors.New("unknown part ID")
 
 	errLowValue = errors.New("low value")
 )
 
 // Device defines a MAX3010x device.
 type Device struct {
-	sensor sensor
+	sensor Sensor
 	redLED *tSeries
 	irLED  *tSeries
 	readCh chan struct{}
@@ -36,9 +41,21 @@ type Device struct {
 
 	bus  string
 	addr uint16
+	conn max30102.Bus
+
+	muxAddr uint16
+	muxCh   int
+
+	sensorOpts []max30102.Option
 
 	beat *beat
 
+	mu          sync.Mutex
+	errs        int
+	reconnects  int
+	reconnected bool
+	onReconnect func()
+
 	// PartID is the byte part ID as set by the manufacturer.
 	// MAX30100: 0x11 or max30100.PartID
 	// MAX30102: 0x15 or max30102.PartID
@@ -46,7 +63,10 @@ type Device struct {
 	RevID  byte
 }
 
-type sensor interface {
+// Sensor defines the functions needed by a Device to read a MAX3010x sensor.
+// The drivers in the max30100 and max30102 packages, as well as the kernel
+// backend in the iio package, implement it.
+type Sensor interface {
 	Temperature() (float64, error)
 	RevID() (byte, error)
 	Reset() error
@@ -76,14 +96,75 @@ func New(options ...Option) (*Device, error) {
 		option(d)
 	}
 
-	sensor, err := max30102.New(d.bus, d.addr)
+	if d.sensor != nil {
+		if err := d.configure(); err != nil {
+			return nil, err
+		}
+		return d.init()
+	}
+
+	bus := d.conn
+	if bus == nil {
+		var err error
+		if bus, err = d.openBus(); err != nil {
+			return nil, err
+		}
+	}
+
+	part, err := bus.Read(maxPartID)
+	if err != nil {
+		bus.Close()
+		return nil, fmt.Errorf("max3010x: could not get part ID: %w", err)
+	}
+
+	switch part {
+	case max30100.PartID:
+		d.sensor, err = max30100.NewWithBus(bus)
+	case max30102.PartID:
+		d.sensor, err = max30102.NewWithBus(bus)
+	default:
+		err = fmt.Errorf("max3010x: part ID %#x: %w", part, ErrUnknownPart)
+	}
 	if err != nil {
+		bus.Close()
+		return nil, err
+	}
+	d.PartID = part
+
+	if err := d.configure(); err != nil {
+		d.sensor.Close()
 		return nil, err
 	}
-	d.sensor = sensor
 
-	d.PartID = max30102.PartID
+	return d.init()
+}
+
+// configure applies the options set by SensorOptions.
+func (d *Device) configure() error {
+	if len(d.sensorOpts) == 0 {
+		return nil
+	}
 
+	s, ok := d.sensor.(interface {
+		Options(...max30102.Option) (max30102.Option, error)
+	})
+	if !ok {
+		return fmt.Errorf("max3010x: could not apply sensor options: %w", ErrWrongDevice)
+	}
+	if _, err := s.Options(d.sensorOpts...); err != nil {
+		return fmt.Errorf("max3010x: could not apply sensor options: %w", err)
+	}
+
+	return nil
+}
+
+func (d *Device) init() (*Device, error) {
+	var err error
+	if p, ok := d.sensor.(interface{ PartID() (byte, error) }); ok {
+		if d.PartID, err = p.PartID(); err != nil {
+			return nil, fmt.Errorf("max3010x: could not get part ID: %w", err)
+		}
+	}
 	if d.RevID, err = d.sensor.RevID(); err != nil {
 		return nil, fmt.Errorf("max3010x: could not get revision ID: %w", err)
 	}
@@ -95,17 +176,29 @@ func New(options ...Option) (*Device, error) {
 
 // Close closes the devices and cleans after itself.
 func (d *Device) Close() {
-	d.sensor.Close()
+	d.exclusive(func() error {
+		d.sensor.Close()
+		return nil
+	})
 }
 
 // Calibrate calibrates the power of each LED.
 func (d *Device) Calibrate() error {
-	return d.sensor.Calibrate()
+	return d.exclusive(d.sensor.Calibrate)
 }
 
 // Temperature returns the current temperature of the device.
 func (d *Device) Temperature() (float64, error) {
-	return d.sensor.Temperature()
+	var t float64
+	err := d.exclusive(func() error {
+		return d.do(func() error {
+			var err error
+			t, err = d.sensor.Temperature()
+			return err
+		})
+	})
+
+	return t, err
 }
 
 // ToMax30102 converts a max3010x device to a max30102 device to access low
@@ -119,26 +212,45 @@ func (d *Device) ToMax30102() (*max30102.Device, error) {
 	return device, nil
 }
 
+// ToMax30100 converts a max3010x device to a max30100 device to access low
+// level functions. Check the package max3010x/max30100 for detailed behavior.
+func (d *Device) ToMax30100() (*max30100.Device, error) {
+	device, ok := d.sensor.(*max30100.Device)
+	if !ok {
+		return nil, ErrWrongDevice
+	}
+
+	return device, nil
+}
+
 // Shutdown sets the device into power-save mode.
 func (d *Device) Shutdown() error {
-	return d.sensor.Shutdown()
+	return d.exclusive(d.sensor.Shutdown)
 }
 
 // Startup wakes the device from power-save mode.
 func (d *Device) Startup() error {
-	return d.sensor.Startup()
+	return d.exclusive(d.sensor.Startup)
 }
 
 func (d *Device) leds() error {
 	select {
 	case <-d.readCh:
-		r, ir, err := d.sensor.IRRedBatch()
+		defer d.release()
+		if err := d.check(); err != nil {
+			return fmt.Errorf("could not get LEDs: %w", err)
+		}
+		var ir, r []float64
+		err := d.do(func() error {
+			var err error
+			ir, r, err = d.sensor.IRRedBatch()
+			return err
+		})
 		if err != nil {
 			return fmt.Errorf("could not get LEDs: %w", err)
 		}
 		d.redLED.add(r...)
 		d.irLED.add(ir...)
-		d.readCh <- struct{}{}
 
 	default:
 		select {
@@ -152,13 +264,18 @@ func (d *Device) leds() error {
 func (d *Device) ledsSingle() error {
 	select {
 	case <-d.readCh:
-		r, ir, err := d.sensor.IRRed()
+		defer d.release()
+		var ir, r float64
+		err := d.do(func() error {
+			var err error
+			ir, r, err = d.sensor.IRRed()
+			return err
+		})
 		if err != nil {
 			return fmt.Errorf("could not get LEDs: %w", err)
 		}
 		d.redLED.add(r)
 		d.irLED.add(ir)
-		d.readCh <- struct{}{}
 	}
 	return nil
 }
@@ -10,10 +10,16 @@ import (
 	"time"
 
 	"github.com/cgxeiji/max3010x"
+	"github.com/cgxeiji/max3010x/max30100"
 	"github.com/cgxeiji/max3010x/max30102"
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "scan" {
+		scan()
+		return
+	}
+
 	sensor, err := max3010x.New()
 	if err != nil {
 		log.Fatal(err)
@@ -22,7 +28,7 @@ func main() {
 
 	// Check which sensor is connected using the PartID.
 	switch sensor.PartID {
-	case 0x11: // TODO: test with MAX30100
+	case max30100.PartID: // TODO: test with MAX30100
 		fmt.Printf("MAX30100 rev.%d detected\n", sensor.RevID)
 	case max30102.PartID:
 		fmt.Printf("MAX30102 rev.%d detected\n", sensor.RevID)
@@ -197,6 +203,21 @@ func main() {
 	wg.Wait()
 }
 
+// scan lists the sensors found on all the I²C buses.
+func scan() {
+	found, err := max3010x.Scan()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(found) == 0 {
+		fmt.Println("no sensors found")
+		return
+	}
+	for _, f := range found {
+		fmt.Println(f)
+	}
+}
+
 func float2bar(n float64) string {
 	block := []string{"", "▏", "▏", "▎", "▍", "▌", "▋", "▊", "▊", "▉"}
 	t := int(n)
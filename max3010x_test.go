@@ -0,0 +1,120 @@
+package max3010x
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/cgxeiji/max3010x/emulator"
+	"github.com/cgxeiji/max3010x/fault"
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+func TestHeartRateSpO2(t *testing.T) {
+	if testing.Short() {
+		t.Skip("takes several seconds of emulated signal")
+	}
+
+	tests := []struct {
+		bpm, spo2 float64
+	}{
+		{72, 97},
+		{110, 92},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(fmt.Sprintf("%.0fbpm %.0f%%", tt.bpm, tt.spo2), func(t *testing.T) {
+			t.Parallel()
+
+			d, err := New(WithBus(emulator.New(emulator.Pulse(tt.bpm, tt.spo2))))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+
+			var hr float64
+			for i := 0; i < 3; i++ {
+				if hr, err = d.HeartRate(); err != nil {
+					t.Fatalf("HeartRate() = %v", err)
+				}
+			}
+			if math.Abs(hr-tt.bpm) > 0.1*tt.bpm {
+				t.Errorf("HeartRate() = %.1f, want %.0f ±10%%", hr, tt.bpm)
+			}
+
+			spo2, err := d.SpO2()
+			if err != nil {
+				t.Fatalf("SpO2() = %v", err)
+			}
+			if math.Abs(spo2-tt.spo2) > 2 {
+				t.Errorf("SpO2() = %.1f, want %.0f ±2", spo2, tt.spo2)
+			}
+		})
+	}
+}
+
+func TestNotDetected(t *testing.T) {
+	d, err := New(WithBus(emulator.New(emulator.Constant(0, 0))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	if _, err := d.SpO2(); !errors.Is(err, ErrNotDetected) {
+		t.Errorf("SpO2() without finger = %v, want ErrNotDetected", err)
+	}
+	if _, err := d.HeartRate(); !errors.Is(err, ErrNotDetected) {
+		t.Errorf("HeartRate() without finger = %v, want ErrNotDetected", err)
+	}
+}
+
+func TestFaults(t *testing.T) {
+	heartRate := (*Device).HeartRate
+	spo2 := (*Device).SpO2
+	temperature := (*Device).Temperature
+
+	tests := []struct {
+		name  string
+		fault fault.Fault
+		read  func(*Device) (float64, error)
+		want  error
+	}{
+		{"HeartRate NACK FIFO", fault.NACK(max30102.FIFOData), heartRate, fault.ErrNACK},
+		{"HeartRate NACK status", fault.NACK(max30102.IntStat1), heartRate, fault.ErrNACK},
+		{"HeartRate read errors", fault.ReadErrors(1, 1), heartRate, fault.ErrRead},
+		{"SpO2 NACK FIFO", fault.NACK(max30102.FIFOData), spo2, fault.ErrNACK},
+		{"SpO2 NACK status", fault.NACK(max30102.IntStat1), spo2, fault.ErrNACK},
+		{"SpO2 NACK write pointer", fault.NACK(max30102.FIFOWrPtr), spo2, fault.ErrNACK},
+		{"SpO2 read errors", fault.ReadErrors(1, 1), spo2, fault.ErrRead},
+		{"Temperature NACK config", fault.NACK(max30102.TempCfg), temperature, fault.ErrNACK},
+		{"Temperature NACK value", fault.NACK(max30102.TempInt), temperature, fault.ErrNACK},
+		{"Temperature read errors", fault.ReadErrors(1, 1), temperature, fault.ErrRead},
+		{"Temperature NACK FIFO", fault.NACK(max30102.FIFOData), temperature, nil},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			bus := fault.Wrap(emulator.New(emulator.Pulse(72, 97)))
+			d, err := New(WithBus(bus))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+
+			// Faults are injected after the sensor is initialized.
+			bus.Faults(tt.fault)
+			_, err = tt.read(d)
+			if tt.want == nil && err != nil {
+				t.Errorf("got error %v, want nil", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
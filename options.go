@@ -1,5 +1,7 @@
 package max3010x
 
+import "github.com/cgxeiji/max3010x/max30102"
+
 // An Option configures a device.
 type Option func(d *Device) Option
 
@@ -23,3 +25,62 @@ func OnAddr(addr uint16) Option {
 		return OnAddr(old)
 	}
 }
+
+// WithBus can be used to communicate with the sensor through a custom bus
+// (e.g. an emulator) instead of opening an I²C bus. When set, OnBus and
+// OnAddr are ignored.
+func WithBus(bus max30102.Bus) Option {
+	return func(d *Device) Option {
+		old := d.conn
+		d.conn = bus
+		return WithBus(old)
+	}
+}
+
+// OnMux can be used to connect to a sensor behind a TCA9548A I²C
+// multiplexer at address addr (0x70 to 0x77), on channel ch (0 to 7).
+// Sensors on the same multiplexer share it and their accesses are
+// serialized.
+func OnMux(addr uint16, ch int) Option {
+	return func(d *Device) Option {
+		oldAddr, oldCh := d.muxAddr, d.muxCh
+		d.muxAddr = addr
+		d.muxCh = ch
+		return OnMux(oldAddr, oldCh)
+	}
+}
+
+// WithSensor can be used to read from an already initialized sensor, such as
+// a kernel IIO device from the iio package, instead of opening a bus. When
+// set, OnBus, OnAddr, OnMux and WithBus are ignored. If the sensor has a
+// PartID() (byte, error) method, it is used to set the part ID of the device.
+func WithSensor(s Sensor) Option {
+	return func(d *Device) Option {
+		old := d.sensor
+		d.sensor = s
+		return WithSensor(old)
+	}
+}
+
+// SensorOptions sets options of the max30102 package (e.g.
+// max30102.SampleAveraging or max30102.ADCRange) to apply to the sensor
+// once it is initialized. They are only supported by MAX30102-compatible
+// sensors, and New fails with ErrWrongDevice otherwise.
+func SensorOptions(options ...max30102.Option) Option {
+	return func(d *Device) Option {
+		old := d.sensorOpts
+		d.sensorOpts = options
+		return SensorOptions(old...)
+	}
+}
+
+// OnReconnect sets a function called each time the sensor is reinitialized
+// after losing power or after repeated bus errors. It is called after the
+// read that reinitialized the sensor, and can use the device.
+func OnReconnect(f func()) Option {
+	return func(d *Device) Option {
+		old := d.onReconnect
+		d.onReconnect = f
+		return OnReconnect(old)
+	}
+}
@@ -0,0 +1,113 @@
+package max3010x
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+// Recovery constants
+const (
+	// maxErrors is the number of consecutive errors after which the sensor
+	// is considered lost.
+	maxErrors = 3
+	// recoverAttempts is the number of times the sensor is reinitialized
+	// before giving up.
+	recoverAttempts = 10
+	minBackoff      = 10 * time.Millisecond
+	maxBackoff      = 1 * time.Second
+)
+
+// reinitializer is implemented by the sensors that can be configured again
+// after losing power, such as *max30102.Device.
+type reinitializer interface {
+	Reinit() error
+}
+
+// do runs f on the sensor. If f fails because the sensor was reset, or after
+// maxErrors consecutive errors, the sensor is reinitialized and f is run
+// again. It must be called while holding the read token (see exclusive).
+func (d *Device) do(f func() error) error {
+	err := f()
+
+	d.mu.Lock()
+	if err == nil {
+		d.errs = 0
+		d.mu.Unlock()
+		return nil
+	}
+	d.errs++
+	lost := d.errs >= maxErrors || errors.Is(err, max30102.ErrDeviceReset)
+	d.mu.Unlock()
+
+	if !lost {
+		return err
+	}
+	if rerr := d.reconnect(); rerr != nil {
+		return fmt.Errorf("%w (%v)", err, rerr)
+	}
+
+	return f()
+}
+
+// check runs the consistency check of the sensor, if it has one, and
+// reinitializes it if it lost its configuration.
+func (d *Device) check() error {
+	c, ok := d.sensor.(interface{ Check() error })
+	if !ok {
+		return nil
+	}
+	return d.do(c.Check)
+}
+
+// reconnect reinitializes the sensor with an exponential backoff. It must be
+// called while holding the read token, and the function set by OnReconnect is
+// called when the token is released.
+func (d *Device) reconnect() error {
+	r, ok := d.sensor.(reinitializer)
+	if !ok {
+		return errors.New("max3010x: sensor cannot be reinitialized")
+	}
+
+	backoff := minBackoff
+	var err error
+	for i := 0; i < recoverAttempts; i++ {
+		if err = r.Reinit(); err == nil {
+			d.mu.Lock()
+			d.errs = 0
+			d.reconnects++
+			d.reconnected = true
+			d.mu.Unlock()
+			return nil
+		}
+
+		time.Sleep(backoff)
+		backoff *= 2
+		if backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+
+	return fmt.Errorf("max3010x: could not reconnect sensor: %w", err)
+}
+
+// release releases the read token of the device, and then calls the function
+// set by OnReconnect if the sensor was reconnected meanwhile, so that it can
+// use the device.
+func (d *Device) release() {
+	d.readCh <- struct{}{}
+
+	d.mu.Lock()
+	f := d.onReconnect
+	if !d.reconnected {
+		f = nil
+	}
+	d.reconnected = false
+	d.mu.Unlock()
+
+	if f != nil {
+		f()
+	}
+}
@@ -0,0 +1,90 @@
+package max3010x
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/cgxeiji/max3010x/emulator"
+	"github.com/cgxeiji/max3010x/fault"
+)
+
+func TestReconnectCallback(t *testing.T) {
+	e := emulator.New(emulator.Pulse(72, 97))
+
+	var d *Device
+	temps := make(chan error, 1)
+	d, err := New(WithBus(e), OnReconnect(func() {
+		// Using the device from the callback must not deadlock.
+		_, err := d.Temperature()
+		temps <- err
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	e.PowerCycle()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- d.exclusive(d.check)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("check() after power cycle = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("check() deadlocked while reconnecting")
+	}
+	select {
+	case err := <-temps:
+		if err != nil {
+			t.Errorf("Temperature() in OnReconnect = %v, want nil", err)
+		}
+	default:
+		t.Error("OnReconnect was not called")
+	}
+	if d.reconnects != 1 {
+		t.Errorf("reconnects = %d, want 1", d.reconnects)
+	}
+}
+
+func TestReconnectWhileStreaming(t *testing.T) {
+	bus := fault.Wrap(emulator.New(emulator.Pulse(72, 97)))
+	d, err := New(WithBus(bus))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	s := d.Stream(ctx)
+	go func() {
+		for range s.Samples() {
+		}
+	}()
+
+	// The resets are detected by the stream, which reinitializes the
+	// sensor while Temperature uses it.
+	for i := 0; ctx.Err() == nil; i++ {
+		if i%5 == 0 {
+			bus.Faults(fault.ResetAfter(10))
+		}
+		if _, err := d.Temperature(); err != nil {
+			t.Errorf("Temperature() while streaming = %v", err)
+		}
+	}
+
+	if err := s.Err(); err != nil {
+		t.Errorf("stream stopped with %v", err)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.reconnects == 0 {
+		t.Error("the sensor was not reconnected")
+	}
+}
@@ -0,0 +1,262 @@
+package remote
+
+import (
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/cgxeiji/max3010x/bridge"
+	"github.com/cgxeiji/max3010x/internal/frame"
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+const fifoDepth = 32
+
+// Client is a max30102.Bus that accesses a remote sensor.
+//
+// To keep batches usable across the network, reading the FIFO write pointer
+// fetches the pointers and all the available samples in a single round trip.
+// Following reads of the FIFO read pointer and data are served from that
+// snapshot, so a whole IRRedBatch only needs a few round trips. When the
+// pointers are equal, the FIFO is fetched whole if the AlmostFull flag was
+// just read from the interrupt status, as the driver does. A Client is safe
+// for concurrent use.
+type Client struct {
+	mu sync.Mutex
+	c  *bridge.Client
+
+	// size is the size in bytes of a FIFO sample, or 0 if unknown.
+	size int
+
+	// snapshot of the FIFO
+	valid       bool
+	wr, ovf, rd byte
+	data        []byte
+	consumed    int
+
+	// almostFull is set if the last status read raised AlmostFull and the
+	// FIFO was not read since the previous one, so that the flag is not
+	// left over from samples already read.
+	almostFull bool
+	fifoRead   bool
+}
+
+// Dial connects to the server at addr (e.g. "raspberrypi:5757") and
+// authenticates with token.
+func Dial(addr, token string) (*Client, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("remote: could not connect: %w", err)
+	}
+
+	c, err := NewClient(conn, token)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// NewClient authenticates with token on an established connection (e.g. a
+// TLS connection) and returns a client that uses it.
+func NewClient(conn net.Conn, token string) (*Client, error) {
+	if err := frame.Write(conn, CmdAuth, []byte(token)); err != nil {
+		return nil, fmt.Errorf("remote: could not authenticate: %w", err)
+	}
+	cmd, _, err := frame.Read(frame.NewReader(conn))
+	if err != nil {
+		return nil, fmt.Errorf("remote: could not authenticate: %w", err)
+	}
+	if cmd != bridge.CmdAck {
+		return nil, ErrUnauthorized
+	}
+
+	return &Client{
+		c: bridge.NewClient(conn),
+	}, nil
+}
+
+// Read reads a single byte from a register.
+func (c *Client) Read(reg byte) (byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch reg {
+	case max30102.FIFOWrPtr:
+		if err := c.snapshot(); err != nil {
+			return 0, err
+		}
+		if c.valid {
+			return c.wr, nil
+		}
+	case max30102.OvfCount:
+		if c.valid {
+			ovf := c.ovf
+			c.ovf = 0
+			return ovf, nil
+		}
+	case max30102.FIFORdPtr:
+		if c.valid {
+			return c.rd, nil
+		}
+	case max30102.IntStat1:
+		state, err := c.c.Read(reg)
+		if err == nil {
+			c.status(state)
+		}
+		return state, err
+	}
+
+	return c.c.Read(reg)
+}
+
+// ReadBytes reads n bytes starting from a register.
+func (c *Client) ReadBytes(reg byte, n int) ([]byte, error) {
+	b := make([]byte, n)
+	if err := c.ReadInto(reg, b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// ReadInto reads len(b) bytes starting from a register into b.
+func (c *Client) ReadInto(reg byte, b []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch reg {
+	case max30102.FIFOWrPtr:
+		if len(b) <= 3 {
+			if err := c.snapshot(); err != nil {
+				return err
+			}
+			if c.valid {
+				copy(b, []byte{c.wr, c.ovf, c.rd})
+				c.ovf = 0
+				return nil
+			}
+		}
+	case max30102.IntStat1:
+		err := c.c.ReadInto(reg, b)
+		if err == nil && len(b) > 0 {
+			c.status(b[0])
+		}
+		return err
+	case max30102.FIFOData:
+		c.fifoRead = true
+		if c.valid {
+			n := copy(b, c.data[c.consumed:])
+			c.consume(n)
+			if n == len(b) {
+				return nil
+			}
+			b = b[n:]
+		}
+	}
+
+	return c.c.ReadInto(reg, b)
+}
+
+// Write writes a byte to a register. Writing the FIFO pointers, which empties
+// the FIFO, or the LED slots discards the snapshot of the FIFO. Samples of the
+// snapshot were already read from the device, so they are kept otherwise.
+func (c *Client) Write(reg, data byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch reg {
+	case max30102.FIFOWrPtr, max30102.OvfCount, max30102.FIFORdPtr:
+		c.valid = false
+	case max30102.ModeCfg, max30102.MultiLedModeS2S1, max30102.MultiLedModeS4S3:
+		c.valid = false
+		c.size = 0
+	}
+
+	return c.c.Write(reg, data)
+}
+
+// Close closes the connection.
+func (c *Client) Close() {
+	c.c.Close()
+}
+
+// snapshot fetches the FIFO pointers and samples, unless there are samples
+// left from the previous snapshot.
+func (c *Client) snapshot() error {
+	if c.valid && c.consumed < len(c.data) {
+		return nil
+	}
+	c.valid = false
+
+	if c.size == 0 {
+		if err := c.sampleSize(); err != nil {
+			return err
+		}
+		if c.size == 0 {
+			// Nothing is being sampled, read the registers directly.
+			return nil
+		}
+	}
+
+	full := c.almostFull
+	c.almostFull = false
+	wr, ovf, rd, data, err := c.c.FIFO(c.size, full)
+	if err != nil {
+		return fmt.Errorf("remote: could not read FIFO: %w", err)
+	}
+	c.wr, c.ovf, c.rd = wr, ovf, rd
+	c.data = data
+	c.consumed = 0
+	c.valid = true
+
+	return nil
+}
+
+// status records a read of IntStat1.
+func (c *Client) status(state byte) {
+	c.almostFull = state&max30102.AlmostFull != 0 && !c.fifoRead
+	c.fifoRead = false
+}
+
+// consume advances the read pointer of the snapshot by n bytes.
+func (c *Client) consume(n int) {
+	before := c.consumed / c.size
+	c.consumed += n
+	c.rd = byte((int(c.rd) + c.consumed/c.size - before) % fifoDepth)
+	if c.consumed >= len(c.data) {
+		c.valid = false
+	}
+}
+
+// sampleSize reads the mode of the remote device to know the size of a FIFO
+// sample.
+func (c *Client) sampleSize() error {
+	mode, err := c.c.Read(max30102.ModeCfg)
+	if err != nil {
+		return fmt.Errorf("remote: could not get mode: %w", err)
+	}
+
+	channels := 0
+	switch mode & 0b111 {
+	case max30102.ModeHR:
+		channels = 1
+	case max30102.ModeSpO2:
+		channels = 2
+	case max30102.ModeMultiLed:
+		slots, err := c.c.ReadBytes(max30102.MultiLedModeS2S1, 2)
+		if err != nil {
+			return fmt.Errorf("remote: could not get LED slots: %w", err)
+		}
+		for _, s := range []byte{slots[0] & 0b111, slots[0] >> 4 & 0b111, slots[1] & 0b111, slots[1] >> 4 & 0b111} {
+			if s == max30102.SlotNone {
+				break
+			}
+			channels++
+		}
+	}
+	c.size = 3 * channels
+
+	return nil
+}
@@ -0,0 +1,192 @@
+package remote
+
+import (
+	"errors"
+	"math"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cgxeiji/max3010x/emulator"
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+// countingBus counts the reads of the FIFO data register made by the server.
+type countingBus struct {
+	max30102.Bus
+
+	mu        sync.Mutex
+	fifoReads int
+}
+
+func (b *countingBus) ReadBytes(reg byte, n int) ([]byte, error) {
+	if reg == max30102.FIFOData {
+		b.mu.Lock()
+		b.fifoReads++
+		b.mu.Unlock()
+	}
+	return b.Bus.ReadBytes(reg, n)
+}
+
+func (b *countingBus) reads() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.fifoReads
+}
+
+// connect serves bus over a pipe and connects a client with token. The
+// server error is sent on the returned channel when the connection ends.
+func connect(t *testing.T, bus max30102.Bus, token string) (*Client, <-chan error, error) {
+	t.Helper()
+
+	server, conn := net.Pipe()
+	done := make(chan error, 1)
+	go func() {
+		done <- NewServer(bus, "secret").ServeConn(server)
+	}()
+
+	c, err := NewClient(conn, token)
+	if err != nil {
+		conn.Close()
+	}
+	return c, done, err
+}
+
+func TestAuth(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		err   error
+	}{
+		{"valid token", "secret", nil},
+		{"wrong token", "guess", ErrUnauthorized},
+		{"empty token", "", ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, done, err := connect(t, emulator.New(emulator.Constant(0, 0)), tt.token)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("NewClient() = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				if err := <-done; !errors.Is(err, ErrUnauthorized) {
+					t.Errorf("ServeConn() = %v, want ErrUnauthorized", err)
+				}
+				return
+			}
+
+			part, err := c.Read(max30102.RegPartID)
+			if err != nil || part != max30102.PartID {
+				t.Errorf("Read(RegPartID) = %#x, %v, want %#x", part, err, max30102.PartID)
+			}
+			c.Close()
+			if err := <-done; err != nil {
+				t.Errorf("ServeConn() after Close = %v, want nil", err)
+			}
+		})
+	}
+}
+
+func TestIRRedBatch(t *testing.T) {
+	bus := &countingBus{Bus: emulator.New(emulator.Constant(0.25, 0.5))}
+	c, _, err := connect(t, bus, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := max30102.NewWithBus(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	for n := 0; n < 2; n++ {
+		time.Sleep(400 * time.Millisecond)
+		before := bus.reads()
+		ir, red, err := d.IRRedBatch()
+		if err != nil {
+			t.Fatalf("IRRedBatch() = %v", err)
+		}
+		if len(ir) != 32 || len(red) != 32 {
+			t.Fatalf("IRRedBatch() returned %d IR and %d red values, want 32", len(ir), len(red))
+		}
+		for i := range ir {
+			if math.Abs(ir[i]-0.5) > 0.001 || math.Abs(red[i]-0.25) > 0.001 {
+				t.Fatalf("IRRedBatch()[%d] = %v, %v, want 0.5, 0.25", i, ir[i], red[i])
+			}
+		}
+		// One dump to drain the FIFO, and one for the batch.
+		if r := bus.reads() - before; r > 2 {
+			t.Errorf("IRRedBatch() read the FIFO data %d times, want at most 2", r)
+		}
+	}
+}
+
+func TestFullFIFO(t *testing.T) {
+	clk := emulator.NewManualClock(time.Now())
+	e := emulator.New(emulator.Constant(0.25, 0.5), emulator.WithClock(clk))
+	bus := &countingBus{Bus: e}
+	c, _, err := connect(t, bus, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+
+	for _, w := range [][2]byte{
+		{max30102.Led1PA, emulator.RefAmplitude},
+		{max30102.Led2PA, emulator.RefAmplitude},
+		{max30102.IntEna1, max30102.AlmostFull},
+		{max30102.ModeCfg, max30102.ModeSpO2},
+	} {
+		if err := c.Write(w[0], w[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := c.Read(max30102.IntStat1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fill the FIFO exactly at 50 samples/s: the pointers are equal and
+	// nothing is lost.
+	clk.Advance(620 * time.Millisecond)
+	p, err := e.ReadBytes(max30102.FIFOWrPtr, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p[0] != p[2] || p[1] != 0 {
+		t.Fatalf("FIFO pointers = %v, want a full FIFO without overflow", p)
+	}
+
+	// As the driver does after waiting for the AlmostFull flag.
+	state, err := c.Read(max30102.IntStat1)
+	if err != nil || state&max30102.AlmostFull == 0 {
+		t.Fatalf("Read(IntStat1) = %#x, %v, want AlmostFull", state, err)
+	}
+	if _, err := c.ReadBytes(max30102.FIFOWrPtr, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	want := make([]byte, 6)
+	for i := 0; i < 32; i++ {
+		if i == 16 {
+			// Writing other registers keeps the samples already read.
+			if err := c.Write(max30102.TempCfg, max30102.TempEna); err != nil {
+				t.Fatal(err)
+			}
+		}
+		b, err := c.ReadBytes(max30102.FIFOData, 6)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			copy(want, b)
+		}
+		if string(b) != string(want) || b[0]|b[1]|b[2] == 0 {
+			t.Fatalf("sample %d = %v, want %v", i, b, want)
+		}
+	}
+	if r := bus.reads(); r != 1 {
+		t.Errorf("the FIFO data was read %d times, want 1", r)
+	}
+}
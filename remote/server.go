@@ -0,0 +1,114 @@
+// Package remote exposes a MAX3010x over TCP, so that the sensor can run on
+// one machine (e.g. a Raspberry Pi) and the analysis on another. The server
+// forwards register accesses to a local bus, and the client is a
+// max30102.Bus that can be passed to max3010x.New with max3010x.WithBus.
+//
+// The protocol is the one of the bridge package, preceded by an
+// authentication frame carrying a shared token:
+//
+//	CmdAuth [token] -> bridge.CmdAck [] or bridge.CmdError [message]
+//
+// The token is sent in clear text. Wrap the listener and connection with TLS
+// when the network is not trusted.
+package remote
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+
+	"github.com/cgxeiji/max3010x/bridge"
+	"github.com/cgxeiji/max3010x/internal/frame"
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+// CmdAuth is the command of the authentication frame.
+const CmdAuth = 0x10
+
+var (
+	// ErrUnauthorized is returned when the token is rejected by the server.
+	ErrUnauthorized = errors.New("remote: unauthorized")
+)
+
+// Server exposes the register operations and FIFO batches of a bus over TCP.
+// Requests from several clients are serialized.
+type Server struct {
+	bus   *lockedBus
+	token []byte
+}
+
+// NewServer returns a new server that forwards the requests of clients
+// presenting token to bus. The bus is usually the one returned by
+// serial.NewI2C, but a *max30102.Device can be used as well. The server never
+// closes the bus.
+func NewServer(bus max30102.Bus, token string) *Server {
+	return &Server{
+		bus: &lockedBus{
+			bus: bus,
+		},
+		token: []byte(token),
+	}
+}
+
+// Serve accepts connections from l and serves each of them in its own
+// goroutine, until l is closed.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return fmt.Errorf("remote: could not accept connection: %w", err)
+		}
+		go s.ServeConn(conn)
+	}
+}
+
+// ServeConn authenticates the client on conn and serves its requests until
+// the connection is closed. The connection is closed on return.
+func (s *Server) ServeConn(conn net.Conn) error {
+	defer conn.Close()
+
+	cmd, token, err := frame.Read(frame.NewReader(conn))
+	if err != nil {
+		return fmt.Errorf("remote: could not authenticate: %w", err)
+	}
+	if cmd != CmdAuth || subtle.ConstantTimeCompare(token, s.token) != 1 {
+		frame.Write(conn, bridge.CmdError, []byte("unauthorized"))
+		return ErrUnauthorized
+	}
+	if err := frame.Write(conn, bridge.CmdAck, nil); err != nil {
+		return err
+	}
+
+	// The client waits for the acknowledgement before sending requests, so
+	// nothing is left behind in the buffer of the authentication reader.
+	return bridge.Serve(conn, s.bus)
+}
+
+// lockedBus serializes the accesses to a bus shared by several connections.
+type lockedBus struct {
+	mu  sync.Mutex
+	bus max30102.Bus
+}
+
+func (b *lockedBus) Read(reg byte) (byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.bus.Read(reg)
+}
+
+func (b *lockedBus) ReadBytes(reg byte, n int) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.bus.ReadBytes(reg, n)
+}
+
+func (b *lockedBus) Write(reg, data byte) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.bus.Write(reg, data)
+}
+
+// Close does nothing, as the bus is owned by the caller of NewServer.
+func (b *lockedBus) Close() {}
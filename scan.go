@@ -0,0 +1,37 @@
+package max3010x
+
+import "fmt"
+
+// Found defines a MAX3010x sensor found by Scan.
+type Found struct {
+	// Bus is the name of the I²C bus (e.g. "/dev/i2c-1").
+	Bus  string
+	Addr uint16
+	// MuxAddr is the address of the TCA9548A multiplexer the sensor is
+	// connected to, or 0 if the sensor is directly on the bus.
+	MuxAddr    uint16
+	MuxChannel int
+
+	PartID byte
+	RevID  byte
+}
+
+func (f Found) String() string {
+	s := fmt.Sprintf("%s@%#x", f.Bus, f.Addr)
+	if f.MuxAddr != 0 {
+		s = fmt.Sprintf("%s@%#x/%d@%#x", f.Bus, f.MuxAddr, f.MuxChannel, f.Addr)
+	}
+	return fmt.Sprintf("%s: part %#x rev %d", s, f.PartID, f.RevID)
+}
+
+// Options returns the options to pass to New to open the sensor.
+func (f Found) Options() []Option {
+	opts := []Option{
+		OnBus(f.Bus),
+		OnAddr(f.Addr),
+	}
+	if f.MuxAddr != 0 {
+		opts = append(opts, OnMux(f.MuxAddr, f.MuxChannel))
+	}
+	return opts
+}
@@ -0,0 +1,127 @@
+//go:build linux && !tinygo
+// +build linux,!tinygo
+
+package max3010x
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/cgxeiji/max3010x/max30100"
+	"github.com/cgxeiji/max3010x/max30102"
+	"github.com/cgxeiji/serial"
+)
+
+// Scan enumerates the I²C buses (/dev/i2c-*) and returns the MAX3010x
+// sensors found on them, either directly at the default address (0x57) or
+// behind a TCA9548A multiplexer (0x70 to 0x77).
+//
+// Probing a multiplexer writes 0x00 to its control register, so any other
+// device answering in that address range receives the same write.
+func Scan() ([]Found, error) {
+	buses, err := filepath.Glob("/dev/i2c-*")
+	if err != nil {
+		return nil, fmt.Errorf("max3010x: could not list I2C buses: %w", err)
+	}
+
+	var found []Found
+	var scanErr error
+	for _, bus := range buses {
+		f, err := scanBus(bus)
+		if err != nil {
+			if scanErr == nil {
+				scanErr = err
+			}
+			continue
+		}
+		found = append(found, f...)
+	}
+
+	if len(found) == 0 && scanErr != nil {
+		return nil, scanErr
+	}
+
+	return found, nil
+}
+
+func scanBus(bus string) ([]Found, error) {
+	dev, err := serial.NewI2C(bus, maxAddr)
+	if err != nil {
+		return nil, fmt.Errorf("max3010x: could not open %s: %w", bus, err)
+	}
+	defer dev.Close()
+
+	// Reading the control register of a multiplexer writes 0x00 first, which
+	// disables all its channels. This way, only the sensors directly on the
+	// bus answer until a channel is selected.
+	muxes := make(map[uint16]*serial.I2C)
+	for addr := uint16(max30102.MuxAddr); addr < max30102.MuxAddr+8; addr++ {
+		ctrl, err := serial.NewI2C(bus, addr)
+		if err != nil {
+			continue
+		}
+		if _, err := ctrl.Read(0); err != nil {
+			ctrl.Close()
+			continue
+		}
+		muxes[addr] = ctrl
+	}
+	defer func() {
+		for _, ctrl := range muxes {
+			ctrl.Close()
+		}
+	}()
+
+	var found []Found
+	if f, ok := probe(dev); ok {
+		f.Bus = bus
+		found = append(found, f)
+	}
+
+	for addr := uint16(max30102.MuxAddr); addr < max30102.MuxAddr+8; addr++ {
+		ctrl, ok := muxes[addr]
+		if !ok {
+			continue
+		}
+		for ch := 0; ch < 8; ch++ {
+			sel := byte(1 << ch)
+			if err := ctrl.Write(sel, sel); err != nil {
+				continue
+			}
+			f, ok := probe(dev)
+			if !ok {
+				continue
+			}
+			f.Bus = bus
+			f.MuxAddr = addr
+			f.MuxChannel = ch
+			found = append(found, f)
+		}
+		ctrl.Write(0, 0)
+	}
+
+	return found, nil
+}
+
+// probe reads the part and revision IDs of the sensor on bus.
+func probe(bus max30102.Bus) (Found, bool) {
+	part, err := bus.Read(maxPartID)
+	if err != nil {
+		return Found{}, false
+	}
+	switch part {
+	case max30100.PartID, max30102.PartID:
+	default:
+		return Found{}, false
+	}
+	rev, err := bus.Read(max30102.RegRevID)
+	if err != nil {
+		return Found{}, false
+	}
+
+	return Found{
+		Addr:   maxAddr,
+		PartID: part,
+		RevID:  rev,
+	}, true
+}
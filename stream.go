@@ -0,0 +1,403 @@
+package max3010x
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/cgxeiji/max3010x/max30102"
+	"github.com/cgxeiji/max3010x/timestamp"
+)
+
+// Flag defines the flags of a Sample.
+type Flag uint8
+
+// Sample flags
+const (
+	// Gap is set on the first sample read after samples were lost, either
+	// because the FIFO overflowed or because reading the sensor failed.
+	Gap Flag = 1 << iota
+	// Overflow is set on the first sample read after the FIFO of the sensor
+	// overflowed.
+	Overflow
+	// HasGreen is set if the sensor samples its green LED.
+	HasGreen
+)
+
+// Sample defines a sample of a Stream.
+type Sample struct {
+	// Index is the number of the sample since the stream started. Lost
+	// samples counted by the sensor are skipped.
+	Index uint64
+	// Time is the estimated time when the sample was taken, corrected for
+	// the drift of the sensor clock (see Stream.Drift).
+	Time time.Time
+
+	// LED values normalized from 0.0 to 1.0. Green is 0 unless HasGreen is
+	// set.
+	Red   float64
+	IR    float64
+	Green float64
+
+	Flags Flag
+}
+
+// Stream defines a continuous acquisition of samples from a device.
+type Stream struct {
+	ch   chan Sample
+	done chan struct{}
+
+	mu     sync.Mutex
+	err    error
+	period time.Duration
+	drift  float64
+}
+
+// fifoReader is implemented by sensors that can read their FIFO in bursts,
+// such as *max30102.Device.
+type fifoReader interface {
+	ReadFIFO() (max30102.Batch, error)
+	Slots() []byte
+	SamplePeriod() time.Duration
+	Rollover() bool
+}
+
+// Stream constants
+const (
+	// maxLost is the highest number of lost samples counted by the sensor.
+	maxLost = 31
+	// streamBatch is the number of samples taken between two reads of the
+	// FIFO. It is well below the depth of the FIFO (32 samples), so that
+	// scheduling delays do not overflow it.
+	streamBatch = 8
+)
+
+// Stream starts reading samples from the device in the background until ctx
+// is done or reading fails after trying to recover the sensor. The samples
+// should be received from Samples without delay, otherwise the FIFO of the
+// sensor overflows and samples are lost.
+//
+// HeartRate and SpO2 read the same FIFO, so they should not be used while
+// streaming.
+func (d *Device) Stream(ctx context.Context) *Stream {
+	s := &Stream{
+		ch:   make(chan Sample),
+		done: make(chan struct{}),
+	}
+
+	go func() {
+		defer close(s.done)
+		defer close(s.ch)
+		s.setErr(d.stream(ctx, s))
+	}()
+
+	return s
+}
+
+// Samples returns the channel of samples. It is closed when the stream
+// stops.
+func (s *Stream) Samples() <-chan Sample {
+	return s.ch
+}
+
+// Err returns the error that stopped the stream, or nil if it was stopped by
+// its context. It waits for the stream to stop.
+func (s *Stream) Err() error {
+	<-s.done
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Period returns the sample period of the sensor estimated with the host
+// clock, or 0 if it is not known yet.
+func (s *Stream) Period() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.period
+}
+
+// Drift returns the relative difference of the estimated sample period of
+// the sensor from its nominal period (e.g. 0.01 if the sensor samples 1%
+// slower than configured).
+func (s *Stream) Drift() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.drift
+}
+
+func (s *Stream) setErr(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// streamer is the acquisition loop of a Stream.
+type streamer struct {
+	d   *Device
+	ctx context.Context
+	s   *Stream
+
+	index uint64
+	flags Flag
+	last  time.Time
+
+	est     *timestamp.Estimator
+	nominal time.Duration
+}
+
+func (d *Device) stream(ctx context.Context, s *Stream) error {
+	st := &streamer{
+		d:   d,
+		ctx: ctx,
+		s:   s,
+	}
+
+	return st.run()
+}
+
+func (st *streamer) run() error {
+	errs := 0
+	for {
+		select {
+		case <-st.ctx.Done():
+			return nil
+		default:
+		}
+
+		var err error
+		if r, ok := st.d.sensor.(fifoReader); ok {
+			err = st.readFIFO(r)
+		} else {
+			err = st.readSingle()
+		}
+		if err == nil {
+			errs = 0
+			continue
+		}
+		if st.ctx.Err() != nil {
+			return nil
+		}
+
+		// d.do recovers the sensor after maxErrors consecutive errors, so
+		// give up only if it is still failing after that.
+		errs++
+		if errs > maxErrors {
+			return err
+		}
+		st.flags |= Gap
+	}
+}
+
+// send sends s with the next index and the pending flags. It returns false
+// if the context is done.
+func (st *streamer) send(s Sample) bool {
+	s.Index = st.index
+	s.Flags |= st.flags
+	st.index++
+	st.flags = 0
+
+	select {
+	case st.s.ch <- s:
+		return true
+	case <-st.ctx.Done():
+		return false
+	}
+}
+
+// read reads the sensor with f, and flags a gap if the sensor was
+// reconnected meanwhile, as its FIFO was emptied.
+func (st *streamer) read(f func() error) error {
+	st.d.mu.Lock()
+	reconnects := st.d.reconnects
+	st.d.mu.Unlock()
+
+	err := st.d.exclusive(func() error {
+		return st.d.do(f)
+	})
+
+	st.d.mu.Lock()
+	if st.d.reconnects != reconnects {
+		st.flags |= Gap
+		// An unknown number of samples were lost, so the indexes are not
+		// continuous anymore.
+		st.resetEstimator()
+	}
+	st.d.mu.Unlock()
+
+	return err
+}
+
+// readFIFO reads the samples in the FIFO of r every streamBatch samples and
+// sends them.
+func (st *streamer) readFIFO(r fifoReader) error {
+	if !st.sleep(st.last.Add(streamBatch * st.nominal)) {
+		return nil
+	}
+
+	// The configuration of the sensor is read while holding the read
+	// token, as it changes when the sensor is reinitialized.
+	var b max30102.Batch
+	var period time.Duration
+	var rollover bool
+	var slots []byte
+	err := st.read(func() error {
+		var err error
+		if b, err = r.ReadFIFO(); err != nil {
+			return err
+		}
+		period, rollover, slots = r.SamplePeriod(), r.Rollover(), r.Slots()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	st.last = now
+	st.estimator(period)
+
+	// With rollover, the oldest samples were overwritten and the lost
+	// samples come before the batch. Otherwise, the newest samples were
+	// dropped and they come after it.
+	lost := uint64(b.Lost)
+	after := b.Gap() && !rollover
+	if b.Gap() && rollover {
+		st.index += lost
+		st.flags |= Gap | Overflow
+		if b.Lost >= maxLost {
+			// More samples may have been lost than counted, so the
+			// indexes are not continuous anymore.
+			st.resetEstimator()
+		}
+	}
+
+	red, ir, green := -1, -1, -1
+	for ch, s := range slots {
+		switch s {
+		case max30102.SlotRed:
+			red = ch
+		case max30102.SlotIR:
+			ir = ch
+		case max30102.SlotGreen:
+			green = ch
+		}
+	}
+
+	n := b.Len()
+	if n == 0 {
+		return nil
+	}
+	first := st.index
+	// The newest sample taken at now is the last one of the batch, or the
+	// last lost one if they come after it. Beyond maxLost, it is unknown,
+	// and the last sample of the batch is only known to be taken before.
+	last := first + uint64(n) - 1
+	if after && b.Lost < maxLost {
+		last += lost
+	}
+	st.observe(last, now)
+
+	for i := 0; i < n; i++ {
+		s := Sample{
+			Time: st.est.Time(first + uint64(i)),
+		}
+		if red >= 0 {
+			s.Red = b.LEDs[red][i]
+		}
+		if ir >= 0 {
+			s.IR = b.LEDs[ir][i]
+		}
+		if green >= 0 {
+			s.Green = b.LEDs[green][i]
+			s.Flags |= HasGreen
+		}
+		if !st.send(s) {
+			return nil
+		}
+	}
+
+	if after {
+		st.index += lost
+		st.flags |= Gap | Overflow
+		if b.Lost >= maxLost {
+			st.resetEstimator()
+		}
+	}
+
+	return nil
+}
+
+// sleep waits until t. It returns false if the context is done before.
+func (st *streamer) sleep(t time.Time) bool {
+	d := time.Until(t)
+	if d <= 0 {
+		return true
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-st.ctx.Done():
+		return false
+	}
+}
+
+// estimator sets up the timestamp estimator for a nominal sample period.
+func (st *streamer) estimator(nominal time.Duration) {
+	if st.est == nil || nominal != st.nominal {
+		st.est = timestamp.New(nominal)
+		st.nominal = nominal
+	}
+}
+
+// resetEstimator forgets the observations of the timestamp estimator.
+func (st *streamer) resetEstimator() {
+	if st.est != nil {
+		st.est.Reset()
+	}
+}
+
+// observe records that sample index was available at t, and publishes the
+// estimated period and drift to the stream.
+func (st *streamer) observe(index uint64, t time.Time) {
+	st.est.Observe(index, t)
+
+	st.s.mu.Lock()
+	st.s.period = st.est.Period()
+	st.s.drift = st.est.Drift()
+	st.s.mu.Unlock()
+}
+
+// readSingle reads one sample from sensors that cannot read their FIFO in
+// bursts and sends it.
+func (st *streamer) readSingle() error {
+	var ir, red float64
+	err := st.read(func() error {
+		var err error
+		ir, red, err = st.d.sensor.IRRed()
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	st.send(Sample{
+		Time: time.Now(),
+		Red:  red,
+		IR:   ir,
+	})
+
+	return nil
+}
+
+// exclusive runs f while holding the read token of the device, so that it
+// does not access the sensor at the same time as other readers.
+func (d *Device) exclusive(f func() error) error {
+	<-d.readCh
+	defer d.release()
+
+	return f()
+}
@@ -0,0 +1,148 @@
+package max3010x
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/cgxeiji/max3010x/emulator"
+	"github.com/cgxeiji/max3010x/fault"
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+// clockWave encodes the time of each sample in its red value.
+var clockWave = emulator.WaveformFunc(func(t time.Duration) (float64, float64) {
+	return t.Seconds() / 100, 0.5
+})
+
+func TestStreamLostSamples(t *testing.T) {
+	tests := []struct {
+		name     string
+		rollover bool
+	}{
+		{"rollover off", false},
+		{"rollover on", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := New(
+				WithBus(emulator.New(clockWave)),
+				SensorOptions(max30102.FIFORollover(tt.rollover)),
+			)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			s := d.Stream(ctx)
+
+			var samples []Sample
+			for s := range s.Samples() {
+				samples = append(samples, s)
+				if len(samples) == 40 {
+					// Stall long enough to overflow the FIFO.
+					time.Sleep(500 * time.Millisecond)
+				}
+				if len(samples) == 120 {
+					break
+				}
+			}
+			cancel()
+			for range s.Samples() {
+			}
+
+			const period = 10 * time.Millisecond
+			at := func(s Sample) time.Duration {
+				return time.Duration(s.Red * 100 * float64(time.Second))
+			}
+			offset := at(samples[0]) - time.Duration(samples[0].Index)*period
+			// The estimated times follow the times the samples were
+			// taken, from a fixed origin.
+			origin := samples[len(samples)-1].Time.Add(-at(samples[len(samples)-1]))
+			overflows := 0
+			for i, s := range samples {
+				if i > 0 && !s.Time.After(samples[i-1].Time) {
+					t.Fatalf("sample %d (index %d) at %v, not after the previous %v",
+						i, s.Index, s.Time, samples[i-1].Time)
+				}
+				if got := s.Time.Sub(origin); math.Abs(float64(got-at(s))) > float64(2*period) {
+					t.Errorf("sample %d (index %d) estimated at %v, taken at %v",
+						i, s.Index, got, at(s))
+				}
+				// The index of each sample must match when it was taken.
+				got := at(s) - time.Duration(s.Index)*period
+				if math.Abs(float64(got-offset)) > float64(period/2) {
+					t.Fatalf("sample %d (index %d) taken at %v, want %v",
+						i, s.Index, at(s), offset+time.Duration(s.Index)*period)
+				}
+				if s.Flags&Overflow == 0 {
+					continue
+				}
+				overflows++
+				// The overflow is flagged on the first sample after the
+				// gap.
+				if i == 0 || s.Index == samples[i-1].Index+1 {
+					t.Errorf("sample %d (index %d) flagged without a gap", i, s.Index)
+				}
+			}
+			if overflows == 0 {
+				t.Error("no overflow flagged after stalling")
+			}
+		})
+	}
+}
+
+func TestStreamContinuous(t *testing.T) {
+	tests := []struct {
+		name  string
+		delay time.Duration
+		pause time.Duration
+	}{
+		{name: "slow consumer", pause: 150 * time.Millisecond},
+		{name: "slow bus", delay: 5 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bus := fault.Wrap(emulator.New(clockWave), fault.Delay(tt.delay))
+			d, err := New(WithBus(bus))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer d.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			s := d.Stream(ctx)
+
+			var prev Sample
+			n := 0
+			for s := range s.Samples() {
+				if n > 0 && (s.Index != prev.Index+1 || s.Flags&Gap != 0) {
+					t.Fatalf("sample %d (index %d, flags %b) after index %d, want no gap",
+						n, s.Index, s.Flags, prev.Index)
+				}
+				prev = s
+				n++
+				if n%50 == 0 {
+					// Delays shorter than the FIFO depth (320ms) lose
+					// nothing.
+					time.Sleep(tt.pause)
+				}
+				if n == 150 {
+					break
+				}
+			}
+			cancel()
+			for range s.Samples() {
+			}
+			if err := s.Err(); err != nil {
+				t.Errorf("Err() = %v, want nil", err)
+			}
+		})
+	}
+}
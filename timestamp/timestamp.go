@@ -0,0 +1,144 @@
+// Package timestamp assigns host times to the samples of a sensor from their
+// index. The sensor samples at a nominal rate set by its own oscillator,
+// which drifts from the host clock, and its samples are read in bursts some
+// time after they are taken. An Estimator continuously fits the actual sample
+// period against the monotonic host clock, so that recordings of different
+// sensors can be aligned.
+package timestamp
+
+import (
+	"time"
+)
+
+// Estimator constants
+const (
+	// window is the number of observations used to fit the period.
+	window = 128
+	// maxDrift bounds the relative drift of the estimated period from the
+	// nominal one. The oscillator of a MAX3010x is within a few percent.
+	maxDrift = 0.1
+)
+
+type observation struct {
+	index uint64
+	t     time.Time
+}
+
+// Estimator estimates the time of each sample of a sensor. It is not safe for
+// concurrent use.
+type Estimator struct {
+	nominal time.Duration
+	period  float64 // in ns
+
+	obs  []observation
+	next int
+
+	origin time.Time
+	first  uint64
+}
+
+// New returns a new Estimator for a sensor sampling every nominal period.
+func New(nominal time.Duration) *Estimator {
+	return &Estimator{
+		nominal: nominal,
+		period:  float64(nominal),
+		obs:     make([]observation, 0, window),
+	}
+}
+
+// Observe records that sample index was available at host time t, i.e. it
+// was read at t and taken at t or before. Observations are expected in
+// increasing order of index and time.
+func (e *Estimator) Observe(index uint64, t time.Time) {
+	if len(e.obs) < window {
+		e.obs = append(e.obs, observation{index: index, t: t})
+	} else {
+		e.obs[e.next] = observation{index: index, t: t}
+		e.next = (e.next + 1) % window
+	}
+
+	e.fit()
+}
+
+// Time returns the estimated host time when sample index was taken. It
+// returns the zero time until the first observation.
+func (e *Estimator) Time(index uint64) time.Time {
+	if e.origin.IsZero() {
+		return time.Time{}
+	}
+	n := float64(index) - float64(e.first)
+
+	return e.origin.Add(time.Duration(n * e.period))
+}
+
+// Period returns the estimated sample period of the sensor, measured with the
+// host clock. It is the nominal period until enough samples are observed.
+func (e *Estimator) Period() time.Duration {
+	return time.Duration(e.period)
+}
+
+// Drift returns the relative difference of the estimated sample period from
+// the nominal one (e.g. 0.01 if the sensor samples 1% slower than nominal).
+func (e *Estimator) Drift() float64 {
+	return (e.period - float64(e.nominal)) / float64(e.nominal)
+}
+
+// Reset forgets the observations, keeping the estimated period. It must be
+// called when the index of the samples is no longer continuous with the
+// previous observations (e.g. after the sensor was reset).
+func (e *Estimator) Reset() {
+	e.obs = e.obs[:0]
+	e.next = 0
+	e.origin = time.Time{}
+}
+
+// fit fits the period with a least squares regression of the times against
+// the indexes, and the origin with the lower envelope of the observations:
+// as each observation is at or after its sample, the earliest one relative
+// to the fitted line has the least latency.
+func (e *Estimator) fit() {
+	base := e.obs[0]
+	for _, o := range e.obs {
+		if o.index < base.index {
+			base = o
+		}
+	}
+
+	if len(e.obs) >= 2 {
+		var mx, my float64
+		for _, o := range e.obs {
+			mx += float64(o.index - base.index)
+			my += float64(o.t.Sub(base.t))
+		}
+		mx /= float64(len(e.obs))
+		my /= float64(len(e.obs))
+
+		var sxy, sxx float64
+		for _, o := range e.obs {
+			dx := float64(o.index-base.index) - mx
+			dy := float64(o.t.Sub(base.t)) - my
+			sxy += dx * dy
+			sxx += dx * dx
+		}
+		if sxx > 0 {
+			p := sxy / sxx
+			lo := float64(e.nominal) * (1 - maxDrift)
+			hi := float64(e.nominal) * (1 + maxDrift)
+			if p >= lo && p <= hi {
+				e.period = p
+			}
+		}
+	}
+
+	// Offset of each observation from the line through base, keeping the
+	// earliest one.
+	min := 0.0
+	for _, o := range e.obs {
+		off := float64(o.t.Sub(base.t)) - float64(o.index-base.index)*e.period
+		if off < min {
+			min = off
+		}
+	}
+	e.first = base.index
+	e.origin = base.t.Add(time.Duration(min))
+}
@@ -0,0 +1,180 @@
+package timestamp_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/cgxeiji/max3010x/timestamp"
+)
+
+const nominal = 20 * time.Millisecond
+
+// sensor simulates a sensor sampling every period, whose samples are read
+// in bursts of batch samples with a random latency of up to maxLatency.
+type sensor struct {
+	start      time.Time
+	period     time.Duration
+	batch      int
+	maxLatency time.Duration
+	rnd        *rand.Rand
+
+	// taken is the number of samples taken so far.
+	taken int
+}
+
+func newSensor(period time.Duration) *sensor {
+	return &sensor{
+		start:      time.Unix(1000, 0),
+		period:     period,
+		batch:      8,
+		maxLatency: 2 * time.Millisecond,
+		rnd:        rand.New(rand.NewSource(1)),
+	}
+}
+
+// at returns when sample n was taken.
+func (s *sensor) at(n int) time.Time {
+	return s.start.Add(time.Duration(n) * s.period)
+}
+
+// read takes the next batch of samples and returns the number of the last
+// one and when it was read.
+func (s *sensor) read() (last uint64, t time.Time) {
+	s.taken += s.batch
+	latency := time.Duration(s.rnd.Int63n(int64(s.maxLatency)))
+	return uint64(s.taken - 1), s.at(s.taken - 1).Add(latency)
+}
+
+func TestDrift(t *testing.T) {
+	tests := []struct {
+		name  string
+		drift float64
+	}{
+		{"nominal", 0},
+		{"1% slow", 0.01},
+		{"3% fast", -0.03},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSensor(time.Duration(float64(nominal) * (1 + tt.drift)))
+			e := timestamp.New(nominal)
+			for i := 0; i < 200; i++ {
+				e.Observe(s.read())
+			}
+
+			if got := e.Drift(); math.Abs(got-tt.drift) > 0.0005 {
+				t.Errorf("Drift() = %.4f, want %.4f", got, tt.drift)
+			}
+			if got, want := e.Period(), s.period; absDuration(got-want) > 10*time.Microsecond {
+				t.Errorf("Period() = %v, want %v", got, want)
+			}
+			// The times are those of the samples, without the latency of
+			// the reads.
+			for _, n := range []int{0, s.taken / 2, s.taken - 1} {
+				if got, want := e.Time(uint64(n)), s.at(n); absDuration(got.Sub(want)) > s.maxLatency/2 {
+					t.Errorf("Time(%d) = %v after the start, want %v", n, got.Sub(s.start), want.Sub(s.start))
+				}
+			}
+		})
+	}
+}
+
+func TestReset(t *testing.T) {
+	s := newSensor(time.Duration(float64(nominal) * 1.01))
+	e := timestamp.New(nominal)
+	if got := e.Time(0); !got.IsZero() {
+		t.Errorf("Time(0) before observing = %v, want the zero time", got)
+	}
+	for i := 0; i < 50; i++ {
+		e.Observe(s.read())
+	}
+	period := e.Period()
+
+	e.Reset()
+	if got := e.Time(0); !got.IsZero() {
+		t.Errorf("Time(0) after Reset = %v, want the zero time", got)
+	}
+	if got := e.Period(); got != period {
+		t.Errorf("Period() after Reset = %v, want %v", got, period)
+	}
+
+	// The indexes start again from 0, and the old observations are not
+	// used to place them.
+	s.start = s.at(s.taken + 100)
+	s.taken = 0
+	last, at := s.read()
+	e.Observe(last, at)
+	if got := e.Time(last); !got.Equal(at) {
+		t.Errorf("Time(%d) after Reset = %v, want %v", last, got, at)
+	}
+	if got, want := e.Time(0), at.Add(-time.Duration(last)*period); absDuration(got.Sub(want)) > time.Microsecond {
+		t.Errorf("Time(0) after Reset = %v, want %v", got, want)
+	}
+}
+
+func TestGap(t *testing.T) {
+	tests := []struct {
+		name string
+		// lost is the number of samples lost, and counted the number of
+		// them accounted for in the indexes.
+		lost, counted int
+		reset         bool
+	}{
+		{name: "counted", lost: 20, counted: 20},
+		{name: "uncounted", lost: 100, counted: 32, reset: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newSensor(time.Duration(float64(nominal) * 1.01))
+			e := timestamp.New(nominal)
+
+			// index is the index of the next sample, and prev the time
+			// given to the previous one.
+			var index uint64
+			var prev time.Time
+			read := func(i int) {
+				last, at := s.read()
+				first := index
+				index += uint64(s.batch)
+				e.Observe(index-1, at)
+				for n := first; n < index; n++ {
+					got := e.Time(n)
+					if !got.After(prev) {
+						t.Fatalf("batch %d: Time(%d) = %v after the start, not after the previous %v",
+							i, n, got.Sub(s.start), prev.Sub(s.start))
+					}
+					// Until enough observations are made, the times are
+					// only roughly those of the samples.
+					if taken := s.at(int(last) - int(index-1-n)); absDuration(got.Sub(taken)) > s.period {
+						t.Fatalf("batch %d: Time(%d) = %v after the start, want about %v",
+							i, n, got.Sub(s.start), taken.Sub(s.start))
+					}
+					prev = got
+				}
+			}
+
+			for i := 0; i < 50; i++ {
+				read(i)
+			}
+			s.taken += tt.lost
+			index += uint64(tt.counted)
+			if tt.reset {
+				e.Reset()
+			}
+			for i := 50; i < 100; i++ {
+				read(i)
+			}
+		})
+	}
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -0,0 +1,106 @@
+package trace
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/cgxeiji/max3010x/max30102"
+)
+
+// Recorder is a max30102.Bus that records every transaction made through
+// another bus. A Recorder is safe for concurrent use.
+type Recorder struct {
+	mu  sync.Mutex
+	bus max30102.Bus
+	enc *json.Encoder
+	err error
+}
+
+// NewRecorder returns a new recorder that writes the transactions made
+// through bus to w.
+func NewRecorder(bus max30102.Bus, w io.Writer) *Recorder {
+	return &Recorder{
+		bus: bus,
+		enc: json.NewEncoder(w),
+	}
+}
+
+// Err returns the first error found while writing the trace.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) record(e Entry, err error) {
+	e.Time = time.Now()
+	if err != nil {
+		e.Err = err.Error()
+	}
+	if r.err != nil {
+		return
+	}
+	if err := r.enc.Encode(e); err != nil {
+		r.err = fmt.Errorf("trace: could not record transaction: %w", err)
+	}
+}
+
+// Read reads a single byte from a register.
+func (r *Recorder) Read(reg byte) (byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, err := r.bus.Read(reg)
+	r.record(Entry{
+		Op:   OpRead,
+		Reg:  reg,
+		N:    1,
+		Data: hex.EncodeToString([]byte{b}),
+	}, err)
+
+	return b, err
+}
+
+// ReadBytes reads n bytes starting from a register.
+func (r *Recorder) ReadBytes(reg byte, n int) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	b, err := r.bus.ReadBytes(reg, n)
+	r.record(Entry{
+		Op:   OpRead,
+		Reg:  reg,
+		N:    n,
+		Data: hex.EncodeToString(b),
+	}, err)
+
+	return b, err
+}
+
+// Write writes a byte to a register.
+func (r *Recorder) Write(reg, data byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.bus.Write(reg, data)
+	r.record(Entry{
+		Op:   OpWrite,
+		Reg:  reg,
+		Data: hex.EncodeToString([]byte{data}),
+	}, err)
+
+	return err
+}
+
+// Close closes the recorded bus.
+func (r *Recorder) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.bus.Close()
+	r.record(Entry{Op: OpClose}, nil)
+}
@@ -0,0 +1,138 @@
+package trace
+
+import (
+	"bufio"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Replay is a max30102.Bus that plays back a recorded trace. Every
+// transaction must match the next entry of the trace. The first mismatch
+// returns a *DivergenceError, and every transaction after it fails with the
+// same error. A Replay is safe for concurrent use.
+type Replay struct {
+	mu      sync.Mutex
+	entries []Entry
+	next    int
+	err     error
+}
+
+// Load reads a trace written by a Recorder.
+func Load(r io.Reader) (*Replay, error) {
+	rp := &Replay{}
+
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 1<<20)
+	for line := 1; s.Scan(); line++ {
+		if len(s.Bytes()) == 0 {
+			continue
+		}
+		var e Entry
+		if err := json.Unmarshal(s.Bytes(), &e); err != nil {
+			return nil, fmt.Errorf("trace: could not parse line %d: %w", line, err)
+		}
+		rp.entries = append(rp.entries, e)
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("trace: could not read trace: %w", err)
+	}
+
+	return rp, nil
+}
+
+// Err returns the divergence found so far, if any.
+func (r *Replay) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+// Remaining returns the number of entries that have not been replayed yet.
+func (r *Replay) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.entries) - r.next
+}
+
+// expect checks that got matches the next entry of the trace and returns
+// it.
+func (r *Replay) expect(got Entry) (Entry, error) {
+	if r.err != nil {
+		return Entry{}, r.err
+	}
+
+	if r.next >= len(r.entries) {
+		r.err = &DivergenceError{Index: r.next, Got: got}
+		return Entry{}, r.err
+	}
+
+	want := r.entries[r.next]
+	if want.Op != got.Op || want.Reg != got.Reg || want.N != got.N ||
+		(got.Op == OpWrite && want.Data != got.Data) {
+		r.err = &DivergenceError{Index: r.next, Want: &want, Got: got}
+		return Entry{}, r.err
+	}
+	r.next++
+
+	if want.Err != "" {
+		return want, errors.New(want.Err)
+	}
+
+	return want, nil
+}
+
+// Read reads a single byte from a register.
+func (r *Replay) Read(reg byte) (byte, error) {
+	b, err := r.ReadBytes(reg, 1)
+	if err != nil {
+		return 0, err
+	}
+
+	return b[0], nil
+}
+
+// ReadBytes reads n bytes starting from a register.
+func (r *Replay) ReadBytes(reg byte, n int) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, err := r.expect(Entry{Op: OpRead, Reg: reg, N: n})
+	if err != nil {
+		return nil, err
+	}
+
+	b, err := hex.DecodeString(e.Data)
+	if err != nil || len(b) != n {
+		r.err = fmt.Errorf("trace: corrupted data in transaction %d", r.next-1)
+		return nil, r.err
+	}
+
+	return b, nil
+}
+
+// Write writes a byte to a register.
+func (r *Replay) Write(reg, data byte) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	_, err := r.expect(Entry{
+		Op:   OpWrite,
+		Reg:  reg,
+		Data: hex.EncodeToString([]byte{data}),
+	})
+
+	return err
+}
+
+// Close checks that the driver closes the bus at the same point as in the
+// trace.
+func (r *Replay) Close() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.expect(Entry{Op: OpClose})
+}
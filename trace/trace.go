@@ -0,0 +1,71 @@
+// Package trace records the register accesses made to a max30102.Bus into a
+// file, and replays them later as a fake bus, so that a session with a
+// misbehaving unit can be reproduced without the hardware.
+//
+// Traces are stored as JSON lines, one Entry per transaction.
+package trace
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Operations
+const (
+	OpRead  = "read"
+	OpWrite = "write"
+	OpClose = "close"
+)
+
+// Entry defines a single transaction on the bus.
+type Entry struct {
+	Time time.Time `json:"t"`
+	Op   string    `json:"op"`
+	Reg  byte      `json:"reg"`
+	// N is the number of bytes read.
+	N int `json:"n,omitempty"`
+	// Data holds the bytes read or written, in hexadecimal.
+	Data string `json:"data,omitempty"`
+	// Err holds the error returned by the bus, if any.
+	Err string `json:"err,omitempty"`
+}
+
+func (e Entry) String() string {
+	switch e.Op {
+	case OpRead:
+		return fmt.Sprintf("read %d bytes from %#02x", e.N, e.Reg)
+	case OpWrite:
+		return fmt.Sprintf("write %s to %#02x", e.Data, e.Reg)
+	}
+	return e.Op
+}
+
+var (
+	// ErrDiverged is returned by a Replay when the driver issues a
+	// transaction that does not match the trace.
+	ErrDiverged = errors.New("trace: transaction diverged from trace")
+)
+
+// DivergenceError describes the first transaction that did not match the
+// trace.
+type DivergenceError struct {
+	// Index is the position of the expected entry in the trace.
+	Index int
+	// Want is the expected entry, or nil if the trace had ended.
+	Want *Entry
+	// Got is the transaction issued by the driver.
+	Got Entry
+}
+
+func (e *DivergenceError) Error() string {
+	if e.Want == nil {
+		return fmt.Sprintf("trace: transaction %d diverged: got %v after the end of the trace", e.Index, e.Got)
+	}
+	return fmt.Sprintf("trace: transaction %d diverged: want %v, got %v", e.Index, e.Want, e.Got)
+}
+
+// Unwrap returns ErrDiverged.
+func (e *DivergenceError) Unwrap() error {
+	return ErrDiverged
+}
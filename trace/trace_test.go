@@ -0,0 +1,197 @@
+package trace_test
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/cgxeiji/max3010x/emulator"
+	"github.com/cgxeiji/max3010x/fault"
+	"github.com/cgxeiji/max3010x/max30102"
+	"github.com/cgxeiji/max3010x/trace"
+)
+
+// session is what the driver does with the bus while a trace is recorded.
+type session struct {
+	temp    float64
+	ir, red []float64
+	// err is the error returned while the bus rejects Led1PA.
+	err error
+}
+
+// run opens a device on bus and reads it. nack is called, if set, to reject
+// the writes to Led1PA before configuring it, and to undo it after.
+func run(t *testing.T, bus max30102.Bus, nack func() func()) session {
+	t.Helper()
+
+	d, err := max30102.NewWithBus(bus)
+	if err != nil {
+		t.Fatalf("NewWithBus() = %v", err)
+	}
+
+	var s session
+	if s.temp, err = d.Temperature(); err != nil {
+		t.Fatalf("Temperature() = %v", err)
+	}
+	if s.ir, s.red, err = d.IRRedBatch(); err != nil {
+		t.Fatalf("IRRedBatch() = %v", err)
+	}
+	if nack != nil {
+		undo := nack()
+		_, s.err = d.Options(max30102.RedPulseAmp(5))
+		undo()
+	}
+	d.Close()
+
+	return s
+}
+
+func TestRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	bus := fault.Wrap(emulator.New(emulator.Pulse(70, 97)))
+	rec := trace.NewRecorder(bus, &buf)
+	want := run(t, rec, func() func() {
+		undo := bus.Faults(fault.NACK(max30102.Led1PA))
+		return func() { bus.Faults(undo) }
+	})
+	if err := rec.Err(); err != nil {
+		t.Fatalf("Recorder.Err() = %v", err)
+	}
+	if !errors.Is(want.err, fault.ErrNACK) {
+		t.Fatalf("Options() while recording = %v, want ErrNACK", want.err)
+	}
+
+	rp, err := trace.Load(&buf)
+	if err != nil {
+		t.Fatalf("Load() = %v", err)
+	}
+	got := run(t, rp, func() func() { return func() {} })
+	if err := rp.Err(); err != nil {
+		t.Fatalf("Replay.Err() = %v", err)
+	}
+	if n := rp.Remaining(); n != 0 {
+		t.Errorf("Remaining() = %d, want 0", n)
+	}
+
+	if got.temp != want.temp {
+		t.Errorf("replayed Temperature() = %v, want %v", got.temp, want.temp)
+	}
+	if len(got.ir) != len(want.ir) {
+		t.Fatalf("replayed IRRedBatch() returned %d samples, want %d", len(got.ir), len(want.ir))
+	}
+	for i := range want.ir {
+		if got.ir[i] != want.ir[i] || got.red[i] != want.red[i] {
+			t.Fatalf("replayed IRRedBatch()[%d] = %v, %v, want %v, %v", i, got.ir[i], got.red[i], want.ir[i], want.red[i])
+		}
+	}
+	// Recorded errors are returned with the same message.
+	if got.err == nil || got.err.Error() != want.err.Error() {
+		t.Errorf("replayed Options() = %v, want %v", got.err, want.err)
+	}
+}
+
+func TestDivergence(t *testing.T) {
+	var buf bytes.Buffer
+	rec := trace.NewRecorder(emulator.New(emulator.Constant(0.25, 0.5)), &buf)
+	d, err := max30102.NewWithBus(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Options(max30102.RedPulseAmp(5)); err != nil {
+		t.Fatal(err)
+	}
+	d.Close()
+	if err := rec.Err(); err != nil {
+		t.Fatal(err)
+	}
+	trc := buf.String()
+
+	// The trace ends with Options reading Led1PA, writing it, and closing.
+	tests := []struct {
+		name string
+		// want and got are the operations and registers of the expected entry
+		// and of the diverging transaction. want is nil if the trace has
+		// ended.
+		want *trace.Entry
+		got  trace.Entry
+		run  func(d *max30102.Device) error
+	}{
+		{
+			name: "different value",
+			want: &trace.Entry{Op: trace.OpWrite, Reg: max30102.Led1PA},
+			got:  trace.Entry{Op: trace.OpWrite, Reg: max30102.Led1PA},
+			run: func(d *max30102.Device) error {
+				_, err := d.Options(max30102.RedPulseAmp(6))
+				return err
+			},
+		},
+		{
+			name: "different register",
+			want: &trace.Entry{Op: trace.OpRead, Reg: max30102.Led1PA},
+			got:  trace.Entry{Op: trace.OpRead, Reg: max30102.Led2PA},
+			run: func(d *max30102.Device) error {
+				_, err := d.Options(max30102.IRPulseAmp(5))
+				return err
+			},
+		},
+		{
+			name: "different operation",
+			want: &trace.Entry{Op: trace.OpRead, Reg: max30102.Led1PA},
+			got:  trace.Entry{Op: trace.OpWrite, Reg: max30102.Led1PA},
+			run: func(d *max30102.Device) error {
+				return d.Write(max30102.Led1PA, 5)
+			},
+		},
+		{
+			name: "after the end",
+			got:  trace.Entry{Op: trace.OpRead, Reg: max30102.RegRevID},
+			run: func(d *max30102.Device) error {
+				if _, err := d.Options(max30102.RedPulseAmp(5)); err != nil {
+					return err
+				}
+				d.Close()
+				_, err := d.RevID()
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rp, err := trace.Load(bytes.NewBufferString(trc))
+			if err != nil {
+				t.Fatal(err)
+			}
+			d, err := max30102.NewWithBus(rp)
+			if err != nil {
+				t.Fatalf("NewWithBus() = %v", err)
+			}
+
+			err = tt.run(d)
+			if !errors.Is(err, trace.ErrDiverged) {
+				t.Fatalf("diverging call = %v, want ErrDiverged", err)
+			}
+			var de *trace.DivergenceError
+			if !errors.As(err, &de) {
+				t.Fatalf("diverging call = %T, want *DivergenceError", err)
+			}
+			switch {
+			case tt.want == nil && de.Want != nil:
+				t.Errorf("DivergenceError.Want = %v, want nil", de.Want)
+			case tt.want != nil && (de.Want == nil || de.Want.Op != tt.want.Op || de.Want.Reg != tt.want.Reg):
+				t.Errorf("DivergenceError.Want = %v, want %v", de.Want, tt.want)
+			}
+			if de.Got.Op != tt.got.Op || de.Got.Reg != tt.got.Reg {
+				t.Errorf("DivergenceError.Got = %v, want %v", de.Got, tt.got)
+			}
+			if !errors.Is(rp.Err(), trace.ErrDiverged) {
+				t.Errorf("Replay.Err() = %v, want ErrDiverged", rp.Err())
+			}
+
+			// Every transaction after the divergence fails.
+			if _, err := rp.Read(max30102.RegPartID); !errors.Is(err, trace.ErrDiverged) {
+				t.Errorf("Read() after divergence = %v, want ErrDiverged", err)
+			}
+		})
+	}
+}